@@ -1,6 +1,6 @@
 module github.com/joaocarmo/advent-of-code/2021/01
 
-go 1.17
+go 1.18
 
 require github.com/joaocarmo/advent-of-code/helpers v0.0.0-00010101000000-000000000000
 
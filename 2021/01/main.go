@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
-	"log"
-	"strconv"
+	"io"
+	"os"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
+var windowSize = flag.Int("window", 3, "number of consecutive measurements summed in part two")
+
 // comparePrevToCurrent compares the previous result to the current result.
 func comparePrevToCurrent(prev, current int) int {
 	if prev > current {
@@ -34,118 +38,165 @@ func getStringFromResult(result int) string {
 	return "no change"
 }
 
-// sumArrayElements sums the elements of an array.
-func sumArrayElements(array []int) int {
-	sum := 0
+// IncreaseCounter counts how many times the sum of a window of consecutive
+// measurements increased.
+type IncreaseCounter struct {
+	window    *helpers.SlidingWindow[int]
+	prev      int
+	hasPrev   bool
+	increases int
+}
+
+// newIncreaseCounter returns a counter with a window of the given size.
+func newIncreaseCounter(size int) *IncreaseCounter {
+	return &IncreaseCounter{window: helpers.NewSlidingWindow[int](size)}
+}
+
+// add adds a measurement and returns how the sum of the window compares to
+// the previous one. It returns false until the window is full, and for the
+// first full window, which has nothing to compare to.
+func (c *IncreaseCounter) add(num int) (int, bool) {
+	c.window.Push(num)
 
-	for _, each := range array {
-		sum += each
+	if !c.window.IsFull() {
+		return 0, false
 	}
 
-	return sum
-}
+	sum := c.window.Sum()
+	prev, hasPrev := c.prev, c.hasPrev
+	c.prev, c.hasPrev = sum, true
 
-// getFinalAnswer returns the total number of times the result increased.
-func getFinalAnswerPartOne(txtlines []string) int {
-	var answer int
-	var prev int
-	var result int
-	var resultStr string
+	if !hasPrev {
+		return 0, false
+	}
 
-	for _, eachline := range txtlines {
-		// we'll convert each line from a string to an integer
-		num, err := strconv.Atoi(eachline)
+	result := comparePrevToCurrent(prev, sum)
+	if result == 1 {
+		c.increases++
+	}
 
-		if err != nil {
-			log.Fatalf("failed converting string to integer: %s", err)
-		}
+	return result, true
+}
 
-		// compare the previous result to the current result
-		if prev != 0 {
-			result = comparePrevToCurrent(prev, num)
-			resultStr = getStringFromResult(result)
+// forEachMeasurement calls the function with every measurement read from the
+// reader, one line at a time.
+func forEachMeasurement(r io.Reader, fn func(num int)) error {
+	scanner := bufio.NewScanner(r)
 
-			if result == 1 {
-				answer++
-			}
-		} else {
-			resultStr = "N/A - no previous measurement"
+	for i := 1; scanner.Scan(); i++ {
+		fields := helpers.SplitFields(scanner.Text(), " ")
+		if len(fields) == 0 {
+			continue
 		}
 
-		prev = num
+		if len(fields) > 1 {
+			return helpers.NewParseError(i, fields[1].Col, scanner.Text(), "expected a single measurement")
+		}
 
-		fmt.Printf("%d\t(%s)\n", num, resultStr)
+		// we'll convert each line from a string to an integer
+		num, err := fields[0].Int()
+		if err != nil {
+			return helpers.AtLine(err, i, scanner.Text())
+		}
+
+		fn(num)
 	}
 
-	return answer
+	return scanner.Err()
 }
 
-// getFinalAnswerPartTwo returns the total number of times the sum of three
-// consecutive measures increased.
-func getFinalAnswerPartTwo(txtlines []string) int {
-	var answer int
-	var prev int
-	var accum []int
-	var sum int
-	var result int
-	var resultStr string
-
-	for _, eachline := range txtlines {
-		// we'll convert each line from a string to an integer
-		num, err := strconv.Atoi(eachline)
+// getFinalAnswer returns the total number of times the sum of a window of
+// consecutive measurements increased, printing how each sum changed.
+func getFinalAnswer(r io.Reader, size int) (int, error) {
+	counter := newIncreaseCounter(size)
+	noPrevious := "N/A - no previous sum"
+	if size == 1 {
+		noPrevious = "N/A - no previous measurement"
+	}
 
-		if err != nil {
-			log.Fatalf("failed converting string to integer: %s", err)
+	err := forEachMeasurement(r, func(num int) {
+		result, ok := counter.add(num)
+		if !counter.window.IsFull() {
+			return
 		}
 
-		// we need to accumulate the previous three measurements
-		if len(accum) < 3 {
-			accum = append(accum, num)
-		} else {
-			// we need to remove the first measurement and add the new one
-			accum = append(accum[1:], num)
+		resultStr := noPrevious
+		if ok {
+			resultStr = getStringFromResult(result)
 		}
 
-		// if we have three measurements, we can calculate the sum
-		if len(accum) == 3 {
-			sum = sumArrayElements(accum)
-		} else {
-			continue
-		}
+		fmt.Printf("%d\t(%s)\n", counter.window.Sum(), resultStr)
+	})
 
-		// compare the previous result to the current result
-		if prev != 0 {
-			result = comparePrevToCurrent(prev, sum)
-			resultStr = getStringFromResult(result)
+	return counter.increases, err
+}
 
-			if result == 1 {
-				answer++
-			}
-		} else {
-			resultStr = "N/A - no previous sum"
+// countIncreases returns the number of increases for windows of each size in
+// a single pass over the reader, so the measurements can be streamed.
+func countIncreases(r io.Reader, sizes ...int) ([]int, error) {
+	counters := make([]*IncreaseCounter, len(sizes))
+	for i, size := range sizes {
+		counters[i] = newIncreaseCounter(size)
+	}
+
+	err := forEachMeasurement(r, func(num int) {
+		for _, counter := range counters {
+			counter.add(num)
 		}
+	})
 
-		prev = sum
+	increases := make([]int, len(counters))
+	for i, counter := range counters {
+		increases[i] = counter.increases
+	}
+
+	return increases, err
+}
 
-		fmt.Printf("%d\t(%s)\n", sum, resultStr)
+// getFinalAnswerFromFile opens the file and returns its final answer for a
+// window of the given size.
+func getFinalAnswerFromFile(filename string, size int) (int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, err
 	}
+	defer file.Close()
 
-	return answer
+	return getFinalAnswer(file, size)
 }
 
 // main is the entry point for the application.
 func main() {
-	// read the file
+	// read the arguments, where "-" streams the measurements from stdin
 	args := helpers.ReadArguments()
 	filename := args[0]
-	txtlines := helpers.ReadFile(filename)
 
-	// get the final answers
-	answerPartOne := getFinalAnswerPartOne(txtlines)
+	if *windowSize < 1 {
+		fmt.Fprintf(os.Stderr, "the window must have at least 1 measurement, got %d\n", *windowSize)
+		os.Exit(1)
+	}
+
+	if filename == "-" {
+		answers, err := countIncreases(os.Stdin, 1, *windowSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed reading the measurements: %s\n", err)
+			os.Exit(1)
+		}
 
-	fmt.Printf("[Part One] The answer is: %d\n", answerPartOne)
+		fmt.Printf("[Part One] The answer is: %d\n", answers[0])
+		fmt.Printf("[Part Two] The answer is: %d\n", answers[1])
 
-	answerPartTwo := getFinalAnswerPartTwo(txtlines)
+		return
+	}
 
-	fmt.Printf("[Part Two] The answer is: %d\n", answerPartTwo)
+	// get the final answers
+	for i, size := range []int{1, *windowSize} {
+		answer, err := getFinalAnswerFromFile(filename, size)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed reading the measurements: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("[Part %s] The answer is: %d\n", []string{"One", "Two"}[i], answer)
+	}
 }
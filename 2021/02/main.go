@@ -1,122 +1,55 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
-// getCommandAndDisplacement returns the command and displacement from a well
-// formed string.
-func getCommandAndDisplacement(line string) (string, int) {
-	// get the command
-	command := ""
-	displacement := 0
+var trajectoryCSV = flag.String("csv", "", "export the trajectories of the submarine to a CSV file")
 
+// getCommandAndDisplacement returns the command and displacement from a
+// string such as "forward 5".
+func getCommandAndDisplacement(line string) (string, int, error) {
 	// split the string using the space as the delimiter
-	split := strings.Split(line, " ")
+	split := strings.Fields(line)
 
-	// get the command
-	command = split[0]
-
-	// get the displacement
-	displacement, _ = strconv.Atoi(split[1])
-
-	return command, displacement
-}
-
-// calculateNewAim calculates the new aim.
-func calculateNewAim(aim int, command string, displacement int) int {
-	if command == "down" {
-		return aim + displacement
-	}
-
-	if command == "up" {
-		return aim - displacement
-	}
-
-	return aim
-}
-
-// calculateNewHorizontalPosition calculates the new horizontal position.
-func calculateNewHorizontalPosition(horizontalPosition int, command string, displacement int) int {
-	if command == "forward" {
-		return horizontalPosition + displacement
-	}
-
-	return horizontalPosition
-}
-
-// calculateNewDepthPartOne calculates the new depth.
-func calculateNewDepthPartOne(depth int, command string, displacement int) int {
-	if command == "down" {
-		return depth + displacement
+	if len(split) != 2 {
+		return "", 0, fmt.Errorf("expected a command and a displacement, got %q", line)
 	}
 
-	if command == "up" {
-		return depth - displacement
-	}
-
-	return depth
-}
-
-// calculateNewDepthPartTwo calculates the new depth.
-func calculateNewDepthPartTwo(aim int, depth int, command string, displacement int) int {
-	if command == "forward" {
-		return depth + aim*displacement
+	// get the displacement
+	displacement, err := strconv.Atoi(split[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid displacement %q", split[1])
 	}
 
-	return depth
+	return split[0], displacement, nil
 }
 
-// getFinalPositionAndDepthPartOne returns the final position and depth of the
-// submarine.
-func getFinalPositionAndDepthPartOne(txtlines []string) (int, int) {
-	// set the starting horizontal position and depth
-	horizontalPosition := 0
-	depth := 0
+// getFinalPositionAndDepth runs the commands with the rules and returns the
+// submarine, printing its state after each step.
+func getFinalPositionAndDepth(txtlines []string, rules RuleSet, withAim bool) (*Submarine, error) {
+	submarine := newSubmarine(rules)
 
-	// calculate the final position and depth
-	for step, eachline := range txtlines {
-		// get the command and displacement
-		command, displacement := getCommandAndDisplacement(eachline)
-
-		// calculate the new horizontal position and depth
-		horizontalPosition = calculateNewHorizontalPosition(horizontalPosition, command, displacement)
-		depth = calculateNewDepthPartOne(depth, command, displacement)
-
-		// print the current step, horizontal position, and depth
-		fmt.Printf("[step %d]\thorizontal position: %d, depth: %d\n", step+1, horizontalPosition, depth)
+	if err := submarine.run(txtlines); err != nil {
+		return nil, err
 	}
 
-	return horizontalPosition, depth
-}
-
-// getFinalPositionAndDepthPartTwo returns the final position and depth of the
-// submarine.
-func getFinalPositionAndDepthPartTwo(txtlines []string) (int, int) {
-	// set the starting horizontal position and depth
-	aim := 0
-	horizontalPosition := 0
-	depth := 0
-
-	// calculate the final position and depth
-	for step, eachline := range txtlines {
-		// get the command and displacement
-		command, displacement := getCommandAndDisplacement(eachline)
-
-		// calculate the new horizontal position and depth
-		aim = calculateNewAim(aim, command, displacement)
-		horizontalPosition = calculateNewHorizontalPosition(horizontalPosition, command, displacement)
-		depth = calculateNewDepthPartTwo(aim, depth, command, displacement)
-
+	for step, s := range submarine.trajectory {
 		// print the current step, aim, horizontal position, and depth
-		fmt.Printf("[step %d]\taim: %d, horizontal position: %d, depth: %d\n", step+1, aim, horizontalPosition, depth)
+		if withAim {
+			fmt.Printf("[step %d]\taim: %d, horizontal position: %d, depth: %d\n", step+1, s.state.aim, s.state.horizontalPosition, s.state.depth)
+		} else {
+			fmt.Printf("[step %d]\thorizontal position: %d, depth: %d\n", step+1, s.state.horizontalPosition, s.state.depth)
+		}
 	}
 
-	return horizontalPosition, depth
+	return submarine, nil
 }
 
 func main() {
@@ -126,7 +59,12 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// get the final position and depth (Part One)
-	finalPosition, finalDepth := getFinalPositionAndDepthPartOne(txtlines)
+	submarineOne, err := getFinalPositionAndDepth(txtlines, partOneRules, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed running the commands: %s\n", err)
+		os.Exit(1)
+	}
+	finalPosition, finalDepth := submarineOne.state.horizontalPosition, submarineOne.state.depth
 
 	// multiply the final position by the final depth (Part One)
 	finalPositionAndDepth := finalPosition * finalDepth
@@ -135,11 +73,32 @@ func main() {
 	fmt.Printf("[Part One] final position: %d, final depth: %d, final position x depth: %d\n", finalPosition, finalDepth, finalPositionAndDepth)
 
 	// get the final position and depth (Part Two)
-	finalPosition, finalDepth = getFinalPositionAndDepthPartTwo(txtlines)
+	submarineTwo, err := getFinalPositionAndDepth(txtlines, partTwoRules, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed running the commands: %s\n", err)
+		os.Exit(1)
+	}
+	finalPosition, finalDepth = submarineTwo.state.horizontalPosition, submarineTwo.state.depth
 
 	// multiply the final position by the final depth (Part Two)
 	finalPositionAndDepth = finalPosition * finalDepth
 
 	// print the final position, depth, and their product (Part Two)
 	fmt.Printf("[Part Two] final position: %d, final depth: %d, final position x depth: %d\n", finalPosition, finalDepth, finalPositionAndDepth)
+
+	// export the trajectories
+	if *trajectoryCSV != "" {
+		file, err := os.Create(*trajectoryCSV)
+		if err == nil {
+			err = writeTrajectoryCSV(file, submarineOne, submarineTwo)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed exporting the trajectory: %s\n", err)
+			os.Exit(1)
+		}
+	}
 }
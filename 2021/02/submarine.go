@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// State is where the submarine is and where it's aiming.
+type State struct {
+	aim                int
+	horizontalPosition int
+	depth              int
+}
+
+// CommandHandler returns the state of the submarine after a command.
+type CommandHandler func(state State, displacement int) State
+
+// RuleSet is what each command does to the submarine.
+type RuleSet struct {
+	name     string
+	handlers map[string]CommandHandler
+}
+
+// partOneRules move the submarine directly up, down and forward.
+var partOneRules = RuleSet{
+	name: "part one",
+	handlers: map[string]CommandHandler{
+		"forward": func(s State, displacement int) State {
+			s.horizontalPosition += displacement
+			return s
+		},
+		"down": func(s State, displacement int) State {
+			s.depth += displacement
+			return s
+		},
+		"up": func(s State, displacement int) State {
+			s.depth -= displacement
+			return s
+		},
+	},
+}
+
+// partTwoRules turn the submarine up and down, and move it forward along its
+// aim.
+var partTwoRules = RuleSet{
+	name: "part two",
+	handlers: map[string]CommandHandler{
+		"forward": func(s State, displacement int) State {
+			s.horizontalPosition += displacement
+			s.depth += s.aim * displacement
+			return s
+		},
+		"down": func(s State, displacement int) State {
+			s.aim += displacement
+			return s
+		},
+		"up": func(s State, displacement int) State {
+			s.aim -= displacement
+			return s
+		},
+	},
+}
+
+// Step is a command executed by the submarine and the state it left it in.
+type Step struct {
+	command      string
+	displacement int
+	state        State
+}
+
+// Submarine executes commands with a rule set and records its trajectory.
+type Submarine struct {
+	rules      RuleSet
+	state      State
+	trajectory []Step
+}
+
+// newSubmarine returns a submarine at the surface following the rules.
+func newSubmarine(rules RuleSet) *Submarine {
+	return &Submarine{rules: rules}
+}
+
+// execute executes a command.
+func (s *Submarine) execute(command string, displacement int) error {
+	handler, ok := s.rules.handlers[command]
+	if !ok {
+		return fmt.Errorf("unknown command %q for the %s rules", command, s.rules.name)
+	}
+
+	s.state = handler(s.state, displacement)
+	s.trajectory = append(s.trajectory, Step{command, displacement, s.state})
+
+	return nil
+}
+
+// run executes every command in the text lines.
+func (s *Submarine) run(txtlines []string) error {
+	for i, line := range txtlines {
+		command, displacement, err := getCommandAndDisplacement(line)
+		if err != nil {
+			return helpers.AtLine(err, i+1, line)
+		}
+
+		if err := s.execute(command, displacement); err != nil {
+			return helpers.AtLine(err, i+1, line)
+		}
+	}
+
+	return nil
+}
+
+// writeTrajectoryCSV writes the trajectories of the submarines as CSV, one row
+// per step.
+func writeTrajectoryCSV(w io.Writer, submarines ...*Submarine) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"rules", "step", "command", "displacement", "aim", "horizontal_position", "depth"})
+	if err != nil {
+		return err
+	}
+
+	for _, submarine := range submarines {
+		for i, step := range submarine.trajectory {
+			err := writer.Write([]string{
+				submarine.rules.name,
+				strconv.Itoa(i + 1),
+				step.command,
+				strconv.Itoa(step.displacement),
+				strconv.Itoa(step.state.aim),
+				strconv.Itoa(step.state.horizontalPosition),
+				strconv.Itoa(step.state.depth),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strings"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+const wordSize = 64
+
+// Bitset is a set of lines of the report, one bit per line.
+type Bitset []uint64
+
+// newBitset returns a bitset with room for n lines.
+func newBitset(n int) Bitset {
+	return make(Bitset, (n+wordSize-1)/wordSize)
+}
+
+// set adds the line to the bitset.
+func (b Bitset) set(i int) {
+	b[i/wordSize] |= 1 << (i % wordSize)
+}
+
+// count returns the number of lines in the bitset.
+func (b Bitset) count() int {
+	total := 0
+
+	for _, word := range b {
+		total += bits.OnesCount64(word)
+	}
+
+	return total
+}
+
+// countAnd returns the number of lines in both bitsets.
+func (b Bitset) countAnd(other Bitset) int {
+	total := 0
+
+	for i, word := range b {
+		total += bits.OnesCount64(word & other[i])
+	}
+
+	return total
+}
+
+// first returns the first line in the bitset, or -1 if it's empty.
+func (b Bitset) first() int {
+	for i, word := range b {
+		if word != 0 {
+			return i*wordSize + bits.TrailingZeros64(word)
+		}
+	}
+
+	return -1
+}
+
+// Report is the diagnostic report stored by columns: the bitset of a column
+// has the lines with a 1 in that position. Lines can be of any width.
+type Report struct {
+	lines   []string
+	columns []Bitset
+	all     Bitset
+}
+
+// newReport returns the report of the binary numbers in the text lines, which
+// must all have the same width.
+func newReport(txtlines []string) (*Report, error) {
+	r := &Report{}
+
+	for i, line := range txtlines {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		if len(r.lines) > 0 && len(line) != len(r.lines[0]) {
+			return nil, helpers.NewParseError(i+1, 0, line, "expected %d bits, got %d", len(r.lines[0]), len(line))
+		}
+
+		if j := strings.IndexFunc(line, func(bit rune) bool { return bit != '0' && bit != '1' }); j >= 0 {
+			return nil, helpers.NewParseError(i+1, j+1, line, "invalid bit %q", line[j])
+		}
+
+		r.lines = append(r.lines, line)
+	}
+
+	if len(r.lines) == 0 {
+		return nil, fmt.Errorf("the report is empty")
+	}
+
+	r.columns = make([]Bitset, len(r.lines[0]))
+	for j := range r.columns {
+		r.columns[j] = newBitset(len(r.lines))
+	}
+
+	r.all = newBitset(len(r.lines))
+
+	for i, line := range r.lines {
+		for j, bit := range line {
+			if bit == '1' {
+				r.columns[j].set(i)
+			}
+		}
+
+		r.all.set(i)
+	}
+
+	return r, nil
+}
+
+// getWidth returns the number of bits of each line.
+func (r *Report) getWidth() int {
+	return len(r.columns)
+}
+
+// countBits counts the zeros and ones in a column among the lines.
+func (r *Report) countBits(column int, lines Bitset) (int, int) {
+	countOne := r.columns[column].countAnd(lines)
+
+	return lines.count() - countOne, countOne
+}
+
+// getBits returns, for every column, the bit chosen by the compare function
+// from the counts of zeros and ones.
+func (r *Report) getBits(compareFn func(countZero int, countOne int) byte) *big.Int {
+	var sb strings.Builder
+
+	for j := range r.columns {
+		sb.WriteByte(compareFn(r.countBits(j, r.all)))
+	}
+
+	value, _ := new(big.Int).SetString(sb.String(), 2)
+
+	return value
+}
+
+// filter keeps the lines with the bit chosen by the compare function, one
+// column at a time, until a single line is left. Each column takes one pass
+// over the packed words, so filtering is O(lines × width / 64).
+func (r *Report) filter(compareFn func(countZero int, countOne int) byte) *big.Int {
+	lines := append(Bitset{}, r.all...)
+	remaining := len(r.lines)
+
+	for j := 0; j < r.getWidth() && remaining > 1; j++ {
+		keep := compareFn(r.countBits(j, lines))
+		filtered := make(Bitset, len(lines))
+
+		for k, word := range lines {
+			if keep == '1' {
+				filtered[k] = word & r.columns[j][k]
+			} else {
+				filtered[k] = word &^ r.columns[j][k]
+			}
+		}
+
+		// if no line has the bit, none is discarded
+		if count := filtered.count(); count > 0 {
+			lines, remaining = filtered, count
+		}
+	}
+
+	value, _ := new(big.Int).SetString(r.lines[lines.first()], 2)
+
+	return value
+}
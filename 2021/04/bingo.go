@@ -1,22 +1,52 @@
 package main
 
+import "fmt"
+
+// Rules are the ways a card can win.
+type Rules struct {
+	diagonals bool
+	blackout  bool
+}
+
+type cell struct {
+	row    int
+	column int
+}
+
 type BingoCard struct {
-	sequence        []int
 	winningSequence []int
 	card            [][]int
 	marked          [][]bool
+	cells           map[int][]cell
+	rowsMarked      []int
+	columnsMarked   []int
+	diagonalsMarked [2]int
+	numMarked       int
+	won             bool
 }
 
-func (b *BingoCard) new(sequence []int, card [][]int) *BingoCard {
-	b.sequence = sequence
+func (b *BingoCard) new(card [][]int) (*BingoCard, error) {
+	size := len(card)
+
 	b.card = card
-	b.marked = make([][]bool, len(card))
+	b.marked = make([][]bool, size)
+	b.cells = make(map[int][]cell)
+	b.rowsMarked = make([]int, size)
+	b.columnsMarked = make([]int, size)
+
+	for i, row := range card {
+		if len(row) != size {
+			return nil, fmt.Errorf("row %d has %d numbers, expected %d for a %dx%d card", i+1, len(row), size, size, size)
+		}
 
-	for i := 0; i < len(b.marked); i++ {
-		b.marked[i] = make([]bool, len(card[i]))
+		b.marked[i] = make([]bool, size)
+
+		for j, number := range row {
+			b.cells[number] = append(b.cells[number], cell{i, j})
+		}
 	}
 
-	return b
+	return b, nil
 }
 
 func (b *BingoCard) getCard() [][]int {
@@ -27,52 +57,57 @@ func (b *BingoCard) getWinningSequence() []int {
 	return b.winningSequence
 }
 
-func (b *BingoCard) getNumberInCardIndex(number int) (int, int) {
-	for i := 0; i < len(b.card); i++ {
-		for j := 0; j < len(b.card[i]); j++ {
-			if b.card[i][j] == number {
-				return i, j
-			}
-		}
-	}
-
-	return -1, -1
+func (b *BingoCard) getSize() int {
+	return len(b.card)
 }
 
-func (b *BingoCard) markCardWithIndex(i int, j int) {
-	if i > -1 && j > -1 {
-		b.marked[i][j] = true
-	}
-}
+// mark marks every cell with the number and returns the cells that were
+// marked, checking whether the card won with the given rules.
+func (b *BingoCard) mark(number int, rules Rules) []cell {
+	var marked []cell
 
-func (b *BingoCard) isRowMarked(i int) bool {
-	if i < 0 {
-		return false
-	}
+	b.winningSequence = append(b.winningSequence, number)
+
+	for _, c := range b.cells[number] {
+		if b.marked[c.row][c.column] {
+			continue
+		}
 
-	row := b.marked[i]
+		b.marked[c.row][c.column] = true
+		b.numMarked++
+		b.rowsMarked[c.row]++
+		b.columnsMarked[c.column]++
 
-	for _, isMarked := range row {
-		if !isMarked {
-			return false
+		if c.row == c.column {
+			b.diagonalsMarked[0]++
+		}
+		if c.row+c.column == b.getSize()-1 {
+			b.diagonalsMarked[1]++
+		}
+
+		marked = append(marked, c)
+
+		if b.completes(c, rules) {
+			b.won = true
 		}
 	}
 
-	return true
+	return marked
 }
 
-func (b *BingoCard) isColumnMarked(i int) bool {
-	if i < 0 {
-		return false
+// completes checks if marking the cell completes what the rules need to win.
+func (b *BingoCard) completes(c cell, rules Rules) bool {
+	size := b.getSize()
+
+	if rules.blackout {
+		return b.numMarked == size*size
 	}
 
-	for _, row := range b.marked {
-		if !row[i] {
-			return false
-		}
+	if b.rowsMarked[c.row] == size || b.columnsMarked[c.column] == size {
+		return true
 	}
 
-	return true
+	return rules.diagonals && (b.diagonalsMarked[0] == size || b.diagonalsMarked[1] == size)
 }
 
 func (b *BingoCard) getUnmarkedNumbers() []int {
@@ -106,36 +141,6 @@ func (b *BingoCard) getScore() int {
 	return sumOfUnmarkedNumbers * finalNumberInWinningSequence
 }
 
-func (b *BingoCard) findWinningSequence() {
-	if len(b.winningSequence) > 0 {
-		return
-	}
-
-	var tempWinningSequence []int
-
-	// loop through the sequence
-	for _, number := range b.sequence {
-		// find the index of the number in the card
-		i, j := b.getNumberInCardIndex(number)
-
-		// mark the number in the card
-		b.markCardWithIndex(i, j)
-
-		// record the winning sequence
-		tempWinningSequence = append(tempWinningSequence, number)
-
-		// check if either a row or a column is marked
-		if b.isRowMarked(i) || b.isColumnMarked(j) {
-			b.winningSequence = tempWinningSequence
-			break
-		}
-	}
-}
-
 func (b *BingoCard) isWinner() bool {
-	if len(b.winningSequence) == 0 {
-		b.findWinningSequence()
-	}
-
-	return len(b.winningSequence) > 0
+	return b.won
 }
@@ -0,0 +1,126 @@
+package main
+
+import "fmt"
+
+// EventKind is what happened during a draw.
+type EventKind int
+
+const (
+	Drawn EventKind = iota
+	Marked
+	Won
+)
+
+// Event is something that happened during a draw. Marked and won events refer
+// to a card, and won events carry the score of the card.
+type Event struct {
+	kind   EventKind
+	draw   int
+	number int
+	card   int
+	row    int
+	column int
+	score  int
+}
+
+// String returns a string representation of the event.
+func (e Event) String() string {
+	switch e.kind {
+	case Marked:
+		return fmt.Sprintf("draw %d: card %d marks %d at row %d, column %d", e.draw, e.card+1, e.number, e.row+1, e.column+1)
+	case Won:
+		return fmt.Sprintf("draw %d: card %d wins with a score of %d", e.draw, e.card+1, e.score)
+	}
+
+	return fmt.Sprintf("draw %d: %d", e.draw, e.number)
+}
+
+// Game is a game of bingo played one draw at a time.
+type Game struct {
+	sequence    []int
+	cards       []*BingoCard
+	rules       Rules
+	draws       int
+	winners     []int
+	subscribers []func(e Event)
+}
+
+// newGame creates a game with the cards, which must all be N×N.
+func newGame(sequence []int, cards [][][]int, rules Rules) (*Game, error) {
+	g := &Game{
+		sequence: sequence,
+		rules:    rules,
+	}
+
+	for i, card := range cards {
+		b, err := (&BingoCard{}).new(card)
+		if err != nil {
+			return nil, fmt.Errorf("card %d: %w", i+1, err)
+		}
+
+		g.cards = append(g.cards, b)
+	}
+
+	return g, nil
+}
+
+// subscribe calls the function with every event from now on.
+func (g *Game) subscribe(fn func(e Event)) {
+	g.subscribers = append(g.subscribers, fn)
+}
+
+// emit sends the event to every subscriber.
+func (g *Game) emit(e Event) {
+	for _, fn := range g.subscribers {
+		fn(e)
+	}
+}
+
+// isOver checks if every number has been drawn or every card has won.
+func (g *Game) isOver() bool {
+	return g.draws >= len(g.sequence) || len(g.winners) == len(g.cards)
+}
+
+// draw draws the next number and marks it on the cards still playing.
+func (g *Game) draw() {
+	if g.isOver() {
+		return
+	}
+
+	number := g.sequence[g.draws]
+	g.draws++
+	g.emit(Event{kind: Drawn, draw: g.draws, number: number})
+
+	for i, card := range g.cards {
+		if card.isWinner() {
+			continue
+		}
+
+		for _, c := range card.mark(number, g.rules) {
+			g.emit(Event{kind: Marked, draw: g.draws, number: number, card: i, row: c.row, column: c.column})
+		}
+
+		if card.isWinner() {
+			g.winners = append(g.winners, i)
+			g.emit(Event{kind: Won, draw: g.draws, number: number, card: i, score: card.getScore()})
+		}
+	}
+}
+
+// play draws numbers until the game is over.
+func (g *Game) play() {
+	for !g.isOver() {
+		g.draw()
+	}
+}
+
+// getWinners returns the cards that won, in the order they won.
+func (g *Game) getWinners() []*BingoCard {
+	winners := make([]*BingoCard, len(g.winners))
+
+	for i, card := range g.winners {
+		winners[i] = g.cards[card]
+	}
+
+	return winners
+}
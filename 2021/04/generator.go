@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// generateGame generates a random order to draw every number below
+// maxNumber in and random N×N cards, each with distinct numbers. The same
+// seed always generates the same game.
+func generateGame(seed int64, numOfCards int, size int, maxNumber int) ([]int, [][][]int, error) {
+	if size < 1 || numOfCards < 1 {
+		return nil, nil, fmt.Errorf("expected at least one card of at least 1x1")
+	}
+
+	if maxNumber < size*size {
+		return nil, nil, fmt.Errorf("%d numbers are not enough to fill a %dx%d card", maxNumber, size, size)
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	sequence := random.Perm(maxNumber)
+	cards := make([][][]int, numOfCards)
+
+	for c := range cards {
+		numbers := random.Perm(maxNumber)[:size*size]
+		cards[c] = make([][]int, size)
+
+		for i := range cards[c] {
+			cards[c][i] = numbers[i*size : (i+1)*size]
+		}
+	}
+
+	return sequence, cards, nil
+}
+
+// formatGame returns the game in the same format as the puzzle input.
+func formatGame(sequence []int, cards [][][]int, maxNumber int) string {
+	var sb strings.Builder
+	width := len(fmt.Sprint(maxNumber - 1))
+
+	sb.WriteString(helpers.IntArrayToString(sequence, ","))
+	sb.WriteString("\n")
+
+	for _, card := range cards {
+		sb.WriteString("\n")
+
+		for _, row := range card {
+			for j, number := range row {
+				if j > 0 {
+					sb.WriteString(" ")
+				}
+				sb.WriteString(fmt.Sprintf("%*d", width, number))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// writeGeneratedGame generates a game and writes it to a new file as a puzzle
+// input. It never overwrites an existing file, such as the real input.
+func writeGeneratedGame(filename string, seed int64, numOfCards int, size int, maxNumber int) error {
+	sequence, cards, err := generateGame(seed, numOfCards, size, maxNumber)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists, refusing to overwrite it", filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.WriteString(formatGame(sequence, cards, maxNumber)); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -1,11 +1,22 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
+var diagonals = flag.Bool("diagonals", false, "diagonals count to win")
+var blackout = flag.Bool("blackout", false, "only a fully marked card wins")
+var printEvents = flag.Bool("events", false, "print every number drawn, marked and every card that wins")
+var generate = flag.Bool("generate", false, "generate a random game into the file, which must not exist yet, before playing it")
+var seed = flag.Int64("seed", 1, "seed of the generated game")
+var numOfCards = flag.Int("cards", 100, "number of cards in the generated game")
+var cardSize = flag.Int("size", 5, "size of the NxN cards in the generated game")
+var maxNumber = flag.Int("numbers", 100, "numbers from 0 up to this one, excluded, are drawn in the generated game")
+
 // cardIsEmpty checks if a card is empty.
 func cardIsEmpty(card [][]int) bool {
 	if len(card) > 0 && len(card[0]) > 0 {
@@ -17,7 +28,7 @@ func cardIsEmpty(card [][]int) bool {
 
 // bingoParse parses the text lines to get the random sequence and the bingo
 // cards.
-func bingoParse(txtlines []string) ([]int, [][][]int) {
+func bingoParse(txtlines []string) ([]int, [][][]int, error) {
 	var sequence []int
 	var cards [][][]int
 	var card [][]int
@@ -28,7 +39,10 @@ func bingoParse(txtlines []string) ([]int, [][][]int) {
 
 	for i, line := range txtlines {
 		if i == 0 {
-			sequence = helpers.StringToIntArray(line, ",")
+			var err error
+			if sequence, err = helpers.StringToIntArray(line, ","); err != nil {
+				return nil, nil, helpers.AtLine(err, i+1, line)
+			}
 			continue
 		}
 
@@ -40,57 +54,33 @@ func bingoParse(txtlines []string) ([]int, [][][]int) {
 			continue
 		}
 
-		cardRow := helpers.StringToIntArray(line, " ")
-		card = append(card, cardRow)
-	}
-
-	return sequence, cards
-}
-
-// findSmallestWinningSequence finds the winning card with the smallest winning
-// sequence.
-func findSmallestWinningSequence(winningCards []BingoCard) BingoCard {
-	var winningCard BingoCard
-
-	for _, card := range winningCards {
-		if len(winningCard.winningSequence) == 0 || len(card.winningSequence) < len(winningCard.winningSequence) {
-			winningCard = card
+		cardRow, err := helpers.StringToIntArray(line, " ")
+		if err != nil {
+			return nil, nil, helpers.AtLine(err, i+1, line)
 		}
+		card = append(card, cardRow)
 	}
 
-	return winningCard
+	return sequence, cards, nil
 }
 
-// findLongestWinningSequence finds the winning card with the longest winning
-// sequence.
-func findLongestWinningSequence(winningCards []BingoCard) BingoCard {
-	var losingCard BingoCard
-
-	for _, card := range winningCards {
-		if len(losingCard.winningSequence) == 0 || len(card.winningSequence) > len(losingCard.winningSequence) {
-			losingCard = card
+// printCard prints the numbers of a card.
+func printCard(card *BingoCard) {
+	for _, row := range card.getCard() {
+		for _, num := range row {
+			fmt.Printf("%2d ", num)
 		}
+		fmt.Println()
 	}
-
-	return losingCard
+	fmt.Println()
 }
 
-// findWinningCard finds the winning cards with the smallest and longest winning
-// sequences.
-func findWinningCard(bingo []int, cards [][][]int) (BingoCard, BingoCard) {
-	var winningCards []BingoCard
-
-	for _, card := range cards {
-		b := BingoCard{}
-
-		b.new(bingo, card)
-
-		if b.isWinner() {
-			winningCards = append(winningCards, b)
-		}
+// printSequence prints the numbers drawn until a card won.
+func printSequence(card *BingoCard) {
+	for _, num := range card.getWinningSequence() {
+		fmt.Printf("%2d ", num)
 	}
-
-	return findSmallestWinningSequence(winningCards), findLongestWinningSequence(winningCards)
+	fmt.Print("\n\n")
 }
 
 // main is the entry point for the application.
@@ -98,50 +88,65 @@ func main() {
 	// read the file
 	args := helpers.ReadArguments()
 	filename := args[0]
+
+	if *generate {
+		err := writeGeneratedGame(filename, *seed, *numOfCards, *cardSize, *maxNumber)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed generating a game: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
 	txtlines := helpers.ReadFile(filename)
 
 	// parses the file for the random sequence and the bingo cards
-	bingo, cards := bingoParse(txtlines)
+	bingo, cards, err := bingoParse(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the bingo cards: %s\n", err)
+		os.Exit(1)
+	}
+
+	game, err := newGame(bingo, cards, Rules{diagonals: *diagonals, blackout: *blackout})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid bingo cards: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *printEvents {
+		game.subscribe(func(e Event) {
+			fmt.Println(e)
+		})
+	}
+
+	// play until every card won or every number is drawn
+	game.play()
 
 	// find the winning and losing cards
-	winningBingoCard, losingBingoCard := findWinningCard(bingo, cards)
+	winners := game.getWinners()
+	if len(winners) == 0 {
+		fmt.Fprintln(os.Stderr, "no card wins")
+		os.Exit(1)
+	}
+	winningBingoCard, losingBingoCard := winners[0], winners[len(winners)-1]
 
 	// print the winning card
-	fmt.Println("winning card:\n")
-	for _, row := range winningBingoCard.getCard() {
-		for _, num := range row {
-			fmt.Printf("%2d ", num)
-		}
-		fmt.Println()
-	}
-	fmt.Println()
+	fmt.Print("winning card:\n\n")
+	printCard(winningBingoCard)
 
 	// print the winning sequence
 	fmt.Printf("winning sequence: ")
-	for _, num := range winningBingoCard.getWinningSequence() {
-		fmt.Printf("%2d ", num)
-	}
-	fmt.Println("\n")
+	printSequence(winningBingoCard)
 
 	// print the final winning score
 	fmt.Printf("final winning score: %d\n", winningBingoCard.getScore())
 
 	// print the losing card
-	fmt.Println("losing card:\n")
-	for _, row := range losingBingoCard.getCard() {
-		for _, num := range row {
-			fmt.Printf("%2d ", num)
-		}
-		fmt.Println()
-	}
-	fmt.Println()
+	fmt.Print("losing card:\n\n")
+	printCard(losingBingoCard)
 
 	// print the losing sequence
 	fmt.Printf("losing sequence: ")
-	for _, num := range losingBingoCard.getWinningSequence() {
-		fmt.Printf("%2d ", num)
-	}
-	fmt.Println("\n")
+	printSequence(losingBingoCard)
 
 	// print the final losing score
 	fmt.Printf("final losing score: %d\n", losingBingoCard.getScore())
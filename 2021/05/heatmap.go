@@ -0,0 +1,93 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// heatColors go from a single vent to the most overlaps on the board.
+var heatColors = []color.RGBA{
+	{40, 40, 120, 255},
+	{200, 40, 40, 255},
+	{255, 220, 60, 255},
+	{255, 255, 255, 255},
+}
+
+// getHeatColor returns the colour of a number of overlapping vents.
+func getHeatColor(count int, maxCount int) color.RGBA {
+	if maxCount <= 1 {
+		return heatColors[0]
+	}
+
+	position := float64(count-1) / float64(maxCount-1) * float64(len(heatColors)-1)
+	i := int(position)
+	if i >= len(heatColors)-1 {
+		return heatColors[len(heatColors)-1]
+	}
+
+	t := position - float64(i)
+	blend := func(from, to uint8) uint8 {
+		return uint8(float64(from) + (float64(to)-float64(from))*t)
+	}
+
+	return color.RGBA{
+		blend(heatColors[i].R, heatColors[i+1].R),
+		blend(heatColors[i].G, heatColors[i+1].G),
+		blend(heatColors[i].B, heatColors[i+1].B),
+		255,
+	}
+}
+
+// getHeatmap returns an image of the board at most maxSize pixels wide or
+// tall. When the board is larger, each pixel shows the most overlapping
+// point it covers.
+func (b *Board) getHeatmap(maxSize int) *image.RGBA {
+	width, height := b.max.x-b.min.x+1, b.max.y-b.min.y+1
+	scale := float64(helpers.MaxOf(width, height)) / float64(maxSize)
+	if scale < 1 {
+		scale = 1
+	}
+
+	imageWidth := helpers.MaxOf(1, int(float64(width)/scale))
+	imageHeight := helpers.MaxOf(1, int(float64(height)/scale))
+	pixels := make([]int, imageWidth*imageHeight)
+	maxCount := 0
+
+	for p, count := range b.counts {
+		x := helpers.MinOf(int(float64(p.x-b.min.x)/scale), imageWidth-1)
+		y := helpers.MinOf(int(float64(p.y-b.min.y)/scale), imageHeight-1)
+
+		pixels[y*imageWidth+x] = helpers.MaxOf(pixels[y*imageWidth+x], count)
+		maxCount = helpers.MaxOf(maxCount, count)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	for i, count := range pixels {
+		if count > 0 {
+			img.SetRGBA(i%imageWidth, i/imageWidth, getHeatColor(count, maxCount))
+		} else {
+			img.SetRGBA(i%imageWidth, i/imageWidth, color.RGBA{0, 0, 0, 255})
+		}
+	}
+
+	return img
+}
+
+// saveHeatmap writes the heatmap of the board to a PNG file.
+func (b *Board) saveHeatmap(filename string, maxSize int) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, b.getHeatmap(maxSize)); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
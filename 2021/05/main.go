@@ -1,29 +1,45 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
+var exact = flag.Bool("exact", false, "only count the points with integer coordinates exactly on the vents, instead of drawing them with Bresenham's algorithm")
+var analytic = flag.Bool("analytic", false, "count the overlaps from the intersections of the vents instead of drawing them, which implies -exact")
+var heatmapFile = flag.String("png", "", "export a heatmap of the overlaps to a PNG file")
+var heatmapSize = flag.Int("size", 1000, "largest width or height of the heatmap")
+
 // parseFileForVents parses the file for vents.
-func parseFileForVents(lines []string) []Vent {
+func parseFileForVents(lines []string) ([]Vent, error) {
 	var vents []Vent
 
-	for _, line := range lines {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
 		// parse the line
 		points := strings.Split(line, " -> ")
+		if len(points) != 2 {
+			return nil, helpers.NewParseError(i+1, 0, line, "expected x1,y1 -> x2,y2")
+		}
 
 		// create a new vent
 		v := Vent{}
-		v.new(points[0], points[1])
+		if _, err := v.new(points[0], points[1]); err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
+		}
 
 		// add the vent to the list
 		vents = append(vents, v)
 	}
 
-	return vents
+	return vents, nil
 }
 
 // main is the entry point for the application.
@@ -33,12 +49,32 @@ func main() {
 	filename := args[0]
 	txtlines := helpers.ReadFile(filename)
 
+	if *analytic && *heatmapFile != "" {
+		fmt.Fprintln(os.Stderr, "-png needs the board, which -analytic doesn't draw")
+		os.Exit(1)
+	}
+
+	// the intersections are exact, so -analytic counts like -exact
+	if *analytic && !*exact {
+		*exact = true
+		fmt.Fprintln(os.Stderr, "-analytic counts the exact points on the vents, as with -exact")
+	}
+
 	// parse the file
-	vents := parseFileForVents(txtlines)
+	vents, err := parseFileForVents(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing vents: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *analytic {
+		fmt.Printf("The number of points with overlap is %d\n", getOverlapAnalytic(vents))
+		return
+	}
 
 	// create the board
 	board := Board{}
-	board.new(vents)
+	board.new(vents, *exact)
 
 	// get the number of points with overlap
 	overlap := board.getOverlap(2)
@@ -48,4 +84,11 @@ func main() {
 
 	// print the number of points with overlap
 	fmt.Printf("The number of points with overlap is %d\n", overlap)
+
+	if *heatmapFile != "" {
+		if err := board.saveHeatmap(*heatmapFile, helpers.MaxOf(1, *heatmapSize)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed saving the heatmap: %s\n", err)
+			os.Exit(1)
+		}
+	}
 }
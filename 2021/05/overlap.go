@@ -0,0 +1,218 @@
+package main
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// Line is the infinite line a vent lies on. Its direction is the smallest step
+// between lattice points on it, pointing right (or down when vertical), and
+// its offset is the cross product of the direction with any of its points.
+type Line struct {
+	direction Point
+	offset    int
+}
+
+// segment is a vent as the positions along its line of its first and last
+// lattice points. The position of a point is its dot product with the
+// direction, so consecutive lattice points are |direction|² apart.
+type segment struct {
+	line     Line
+	from, to int
+	vent     Vent
+}
+
+// getPosition returns the position of a point along the line.
+func (l Line) getPosition(p Point) int {
+	return p.x*l.direction.x + p.y*l.direction.y
+}
+
+// getStep returns the distance between consecutive lattice points along the
+// line.
+func (l Line) getStep() int {
+	return l.direction.x*l.direction.x + l.direction.y*l.direction.y
+}
+
+// getSegment returns the vent as a segment of its line. A vent of a single
+// point is on the horizontal line through it.
+func (v *Vent) getSegment() segment {
+	direction, _ := v.getStep()
+
+	if direction.x < 0 || (direction.x == 0 && direction.y < 0) {
+		direction = Point{-direction.x, -direction.y}
+	}
+
+	if direction == (Point{0, 0}) {
+		direction = Point{1, 0}
+	}
+
+	line := Line{direction, direction.x*v.start.y - direction.y*v.start.x}
+	from, to := line.getPosition(v.start), line.getPosition(v.end)
+
+	return segment{line, helpers.MinOf(from, to), helpers.MaxOf(from, to), *v}
+}
+
+// interval is a range of positions along a line, both ends included.
+type interval struct {
+	from, to int
+}
+
+// getOverlappingIntervals returns the ranges of positions along a line
+// covered by at least n of the segments on it.
+func getOverlappingIntervals(segments []segment, n int) []interval {
+	type event struct {
+		position, change int
+	}
+
+	step := segments[0].line.getStep()
+	events := make([]event, 0, 2*len(segments))
+
+	for _, s := range segments {
+		events = append(events, event{s.from, 1}, event{s.to + step, -1})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].position < events[j].position
+	})
+
+	intervals := []interval{}
+	coverage := 0
+
+	for i, e := range events {
+		coverage += e.change
+
+		if i+1 < len(events) && coverage >= n && events[i+1].position > e.position {
+			next := events[i+1].position - step
+
+			// merge with the previous interval if they touch
+			if last := len(intervals) - 1; last >= 0 && intervals[last].to+step == e.position {
+				intervals[last].to = next
+			} else {
+				intervals = append(intervals, interval{e.position, next})
+			}
+		}
+	}
+
+	return intervals
+}
+
+// contains checks if a position is in one of the sorted intervals.
+func contains(intervals []interval, position int) bool {
+	i := sort.Search(len(intervals), func(i int) bool {
+		return intervals[i].to >= position
+	})
+
+	return i < len(intervals) && intervals[i].from <= position
+}
+
+// getIntersection returns the lattice point where two segments on different
+// lines cross, if any. The products can exceed an int for coordinates in the
+// millions, so they are computed with big integers.
+func getIntersection(a, b segment) (Point, bool) {
+	// skip the segments whose bounding boxes don't overlap
+	if helpers.MaxOf(a.vent.start.x, a.vent.end.x) < helpers.MinOf(b.vent.start.x, b.vent.end.x) ||
+		helpers.MaxOf(b.vent.start.x, b.vent.end.x) < helpers.MinOf(a.vent.start.x, a.vent.end.x) ||
+		helpers.MaxOf(a.vent.start.y, a.vent.end.y) < helpers.MinOf(b.vent.start.y, b.vent.end.y) ||
+		helpers.MaxOf(b.vent.start.y, b.vent.end.y) < helpers.MinOf(a.vent.start.y, a.vent.end.y) {
+		return Point{}, false
+	}
+
+	d1, d2 := a.line.direction, b.line.direction
+	o1, o2 := big.NewInt(int64(a.line.offset)), big.NewInt(int64(b.line.offset))
+	det := big.NewInt(int64(d1.x*d2.y - d1.y*d2.x))
+
+	// solve d.x*y - d.y*x = offset for both lines
+	numX := new(big.Int).Sub(new(big.Int).Mul(o1, big.NewInt(int64(d2.x))), new(big.Int).Mul(o2, big.NewInt(int64(d1.x))))
+	numY := new(big.Int).Sub(new(big.Int).Mul(o1, big.NewInt(int64(d2.y))), new(big.Int).Mul(o2, big.NewInt(int64(d1.y))))
+
+	x, remX := new(big.Int).QuoRem(numX, det, new(big.Int))
+	y, remY := new(big.Int).QuoRem(numY, det, new(big.Int))
+
+	if remX.Sign() != 0 || remY.Sign() != 0 {
+		return Point{}, false
+	}
+
+	p := Point{int(x.Int64()), int(y.Int64())}
+
+	for _, s := range []segment{a, b} {
+		if position := s.line.getPosition(p); position < s.from || position > s.to {
+			return Point{}, false
+		}
+	}
+
+	return p, true
+}
+
+// getOverlapAnalytic counts the lattice points covered by at least two vents
+// without rasterizing them. Vents on the same line overlap over intervals
+// found with a sweep, and vents on different lines cross at single points.
+// Every vent is treated as its exact lattice points, which are also what
+// Bresenham's algorithm draws for horizontal, vertical and 45° vents.
+func getOverlapAnalytic(vents []Vent) int {
+	segmentsByLine := make(map[Line][]segment)
+	for i := range vents {
+		s := vents[i].getSegment()
+		segmentsByLine[s.line] = append(segmentsByLine[s.line], s)
+	}
+
+	overlapping := make(map[Line][]interval, len(segmentsByLine))
+	lines := make([]Line, 0, len(segmentsByLine))
+	total := 0
+
+	for line, segments := range segmentsByLine {
+		lines = append(lines, line)
+		overlapping[line] = getOverlappingIntervals(segments, 2)
+
+		for _, i := range overlapping[line] {
+			total += (i.to-i.from)/line.getStep() + 1
+		}
+	}
+
+	// the lines through every point where vents on different lines cross
+	crossings := make(map[Point]map[Line]bool)
+
+	for i, l1 := range lines {
+		for _, l2 := range lines[i+1:] {
+			if l1.direction == l2.direction {
+				continue
+			}
+
+			for _, a := range segmentsByLine[l1] {
+				for _, b := range segmentsByLine[l2] {
+					p, ok := getIntersection(a, b)
+					if !ok {
+						continue
+					}
+
+					if crossings[p] == nil {
+						crossings[p] = make(map[Line]bool)
+					}
+					crossings[p][l1] = true
+					crossings[p][l2] = true
+				}
+			}
+		}
+	}
+
+	// a crossing is a new overlap unless it is in an overlap of one of its
+	// lines, and is counted once however many lines it overlaps on
+	for p, crossingLines := range crossings {
+		overlapsOn := 0
+
+		for line := range crossingLines {
+			if contains(overlapping[line], line.getPosition(p)) {
+				overlapsOn++
+			}
+		}
+
+		if overlapsOn == 0 {
+			total++
+		} else {
+			total -= overlapsOn - 1
+		}
+	}
+
+	return total
+}
@@ -8,152 +8,151 @@ import (
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
-type VentPoint struct {
-	x     int
-	y     int
-	count int
+const maxPrintableArea = 1 << 20
+
+type Point struct {
+	x int
+	y int
 }
 
-func (p *VentPoint) toString() string {
-	if p.count == 0 {
-		return "."
+func parsePoint(s string) (Point, error) {
+	coordinates := strings.Split(strings.TrimSpace(s), ",")
+	if len(coordinates) != 2 {
+		return Point{}, fmt.Errorf("invalid point %q, expected x,y", s)
+	}
+
+	x, err := strconv.Atoi(coordinates[0])
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid x in point %q", s)
 	}
 
-	return fmt.Sprintf("%d", p.count)
+	y, err := strconv.Atoi(coordinates[1])
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid y in point %q", s)
+	}
+
+	return Point{x, y}, nil
 }
 
 type Vent struct {
-	start VentPoint
-	end   VentPoint
+	start Point
+	end   Point
 }
 
-func (v *Vent) new(start string, end string) *Vent {
-	// parse the start and end points
-	startPoints := strings.Split(start, ",")
-	endPoints := strings.Split(end, ",")
+func (v *Vent) new(start string, end string) (*Vent, error) {
+	var err error
 
-	// convert the points to integers
-	startX, _ := strconv.Atoi(startPoints[0])
-	startY, _ := strconv.Atoi(startPoints[1])
-	endX, _ := strconv.Atoi(endPoints[0])
-	endY, _ := strconv.Atoi(endPoints[1])
+	// parse the start and end points
+	if v.start, err = parsePoint(start); err != nil {
+		return nil, err
+	}
 
-	// add the start and end points
-	v.start = VentPoint{startX, startY, 1}
-	v.end = VentPoint{endX, endY, 1}
+	if v.end, err = parsePoint(end); err != nil {
+		return nil, err
+	}
 
-	return v
+	return v, nil
 }
 
-type Board struct {
-	grid [][]VentPoint
-}
+// getStep returns the smallest step from one lattice point of the vent to the
+// next one, and the number of steps from the start to the end.
+func (v *Vent) getStep() (Point, int) {
+	dx := v.end.x - v.start.x
+	dy := v.end.y - v.start.y
+	steps := helpers.GCD(helpers.AbsInt(dx), helpers.AbsInt(dy))
 
-func (b *Board) new(vents []Vent) *Board {
-	// find the size of the board
-	maxX, maxY := b.findBoardSizeFromVents(vents)
+	if steps == 0 {
+		return Point{0, 0}, 0
+	}
 
-	// create the grid
-	b.grid = make([][]VentPoint, maxY+1)
+	return Point{dx / steps, dy / steps}, steps
+}
 
-	for i := 0; i < maxY+1; i++ {
-		b.grid[i] = make([]VentPoint, maxX+1)
-	}
+// forEachLatticePoint calls the function with every point with integer
+// coordinates exactly on the vent.
+func (v *Vent) forEachLatticePoint(fn func(p Point)) {
+	step, steps := v.getStep()
 
-	// add the vent points to the grid
-	for _, vent := range vents {
-		b.addVentPoints(vent)
+	for i := 0; i <= steps; i++ {
+		fn(Point{v.start.x + i*step.x, v.start.y + i*step.y})
 	}
-
-	return b
 }
 
-func (b *Board) findBoardSizeFromVents(vents []Vent) (int, int) {
-	// find the max x and y values
-	var maxX, maxY int
-	for _, vent := range vents {
-		if vent.start.y > maxY {
-			maxY = vent.start.y
-		}
-		if vent.end.y > maxY {
-			maxY = vent.end.y
+// forEachPixel calls the function with the points closest to the vent, one
+// for every unit along its longest axis, using Bresenham's line algorithm.
+// For horizontal, vertical and 45° vents they are its lattice points.
+func (v *Vent) forEachPixel(fn func(p Point)) {
+	dx := helpers.AbsInt(v.end.x - v.start.x)
+	dy := -helpers.AbsInt(v.end.y - v.start.y)
+	sx := helpers.SignInt(v.end.x - v.start.x)
+	sy := helpers.SignInt(v.end.y - v.start.y)
+	err := dx + dy
+	p := v.start
+
+	for {
+		fn(p)
+
+		if p == v.end {
+			return
 		}
 
-		if vent.start.x > maxX {
-			maxX = vent.start.x
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p.x += sx
 		}
-		if vent.end.x > maxX {
-			maxX = vent.end.x
+		if e2 <= dx {
+			err += dx
+			p.y += sy
 		}
 	}
-
-	// return the max and min x and y values
-	return maxX, maxY
 }
 
-func (b *Board) addVentPoint(point VentPoint) {
-	currentPoint := b.grid[point.y][point.x]
-
-	// add the point to the grid
-	b.grid[point.y][point.x] = VentPoint{point.x, point.y, currentPoint.count + point.count}
+// Board counts how many vents cover each point. Only the points covered by a
+// vent are stored, so the board can span any coordinates.
+type Board struct {
+	counts map[Point]int
+	min    Point
+	max    Point
 }
 
-func (b *Board) addVentPoints(vent Vent) {
-	if vent.start.x == vent.end.x {
-		// add the vertical points
-		startX := helpers.MinOf(vent.start.y, vent.end.y)
-		endX := helpers.MaxOf(vent.start.y, vent.end.y)
-		for x := startX; x <= endX; x++ {
-			b.addVentPoint(VentPoint{vent.start.x, x, vent.start.count})
-		}
-	}
+func (b *Board) new(vents []Vent, exact bool) *Board {
+	b.counts = make(map[Point]int)
 
-	if vent.start.y == vent.end.y {
-		// add the horizontal points
-		startY := helpers.MinOf(vent.start.x, vent.end.x)
-		endY := helpers.MaxOf(vent.start.x, vent.end.x)
-		for y := startY; y <= endY; y++ {
-			b.addVentPoint(VentPoint{y, vent.start.y, vent.start.count})
-		}
+	// find the bounds of the board
+	if len(vents) > 0 {
+		b.min, b.max = vents[0].start, vents[0].start
 	}
 
-	// add the diagonal points
-	startX := vent.start.x
-	endX := vent.end.x
-	startY := vent.start.y
-	endY := vent.end.y
-
-	// check if the slope is 1
-	var slope int
-
-	if endX-startX != 0 {
-		slope = (endY - startY) / (endX - startX)
+	for _, vent := range vents {
+		for _, p := range []Point{vent.start, vent.end} {
+			b.min = Point{helpers.MinOf(b.min.x, p.x), helpers.MinOf(b.min.y, p.y)}
+			b.max = Point{helpers.MaxOf(b.max.x, p.x), helpers.MaxOf(b.max.y, p.y)}
+		}
 	}
 
-	if helpers.AbsInt(slope) == 1 {
-		// add the diagonal points
-		if startX < endX {
-			for x := startX; x <= endX; x++ {
-				y := startY + slope*(x-startX)
-				b.addVentPoint(VentPoint{x, y, vent.start.count})
-			}
+	// add the vent points to the board
+	for _, vent := range vents {
+		if exact {
+			vent.forEachLatticePoint(b.addVentPoint)
 		} else {
-			for x := startX; x >= endX; x-- {
-				y := startY + slope*(x-startX)
-				b.addVentPoint(VentPoint{x, y, vent.start.count})
-			}
+			vent.forEachPixel(b.addVentPoint)
 		}
 	}
+
+	return b
+}
+
+func (b *Board) addVentPoint(p Point) {
+	b.counts[p]++
 }
 
 func (b *Board) getOverlap(n int) int {
 	var count int
 
-	for _, row := range b.grid {
-		for _, cell := range row {
-			if cell.count >= n {
-				count++
-			}
+	for _, c := range b.counts {
+		if c >= n {
+			count++
 		}
 	}
 
@@ -161,14 +160,26 @@ func (b *Board) getOverlap(n int) int {
 }
 
 func (b *Board) toString() string {
-	var s string
+	var sb strings.Builder
+
+	// the board is drawn from the origin, like the puzzle does
+	minX, minY := helpers.MinOf(b.min.x, 0), helpers.MinOf(b.min.y, 0)
+	width, height := b.max.x-minX+1, b.max.y-minY+1
 
-	for _, row := range b.grid {
-		for _, cell := range row {
-			s += cell.toString()
+	if width*height > maxPrintableArea {
+		return fmt.Sprintf("The board is too large to print (%dx%d)\n", width, height)
+	}
+
+	for y := minY; y <= b.max.y; y++ {
+		for x := minX; x <= b.max.x; x++ {
+			if count := b.counts[Point{x, y}]; count > 0 {
+				sb.WriteString(strconv.Itoa(count))
+			} else {
+				sb.WriteString(".")
+			}
 		}
-		s += "\n"
+		sb.WriteString("\n")
 	}
 
-	return s
+	return sb.String()
 }
@@ -1,18 +1,25 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"math/big"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
-const useVersion = 2
+const useVersion = 3
 const verbose = false
 const dayThreshold = 20
 const daysToCount = 256
 
+var days = flag.Uint64("days", daysToCount, "number of days to count the fish for")
+var exact = flag.Bool("big", false, "print the exact number of fish, even beyond the range of an int")
+var modulo = flag.String("mod", "", "count the fish modulo this number, which works for any number of days")
+
 // getInitialFish returns the initial fish
 func getInitialFish(initialState []int) []*LanternFish {
 	initialFish := make([]*LanternFish, len(initialState))
@@ -142,6 +149,48 @@ func getFishAfterDays(initialFish []*LanternFish, daysToCount int) int {
 	return getFishAfterDaysV2(initialFish, daysToCount)
 }
 
+// getModulo returns the modulo given on the command line, or nil if none
+func getModulo() (*big.Int, error) {
+	if *modulo == "" {
+		return nil, nil
+	}
+
+	m, ok := new(big.Int).SetString(*modulo, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid modulo %q", *modulo)
+	}
+
+	return m, nil
+}
+
+// countFish returns the number of fish after the days given on the command
+// line, as a string since it may not fit in an int
+func countFish(initialState []int) (string, error) {
+	if useVersion != 3 {
+		if *days > maxExactDays {
+			return "", fmt.Errorf("version %d cannot count the fish for %d days", useVersion, *days)
+		}
+
+		return strconv.Itoa(getFishAfterDays(getInitialFish(initialState), int(*days))), nil
+	}
+
+	m, err := getModulo()
+	if err != nil {
+		return "", err
+	}
+
+	fishAfterDays, err := getFishAfterDaysV3(initialState, *days, m)
+	if err != nil {
+		return "", err
+	}
+
+	if m == nil && !*exact && !fishAfterDays.IsInt64() {
+		return "", fmt.Errorf("the number of fish after %d days overflows an int, use -big or -mod", *days)
+	}
+
+	return fishAfterDays.String(), nil
+}
+
 // main is the entry point for the application.
 func main() {
 	// read the file
@@ -150,21 +199,27 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// get the initial state
-	initialState := helpers.GetInitialState(txtlines)
+	initialState, err := helpers.GetInitialState(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the initial state: %s\n", err)
+		os.Exit(1)
+	}
 
 	// print the initial state
 	fmt.Printf("Initial state:\t%s\n", helpers.IntArrayToString(initialState, ","))
 
-	// create the initial fish
-	initialFish := getInitialFish(initialState)
-
 	// get the fish after a set number of days
 	start := time.Now()
-	fishAfterDays := getFishAfterDays(initialFish, daysToCount)
+	fishAfterDays, err := countFish(initialState)
 	elapsed := time.Since(start)
 
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed counting the fish: %s\n", err)
+		os.Exit(1)
+	}
+
 	// print the text lines
-	fmt.Printf("\nFinal number of fish: %d\n", fishAfterDays)
+	fmt.Printf("\nFinal number of fish: %s\n", fishAfterDays)
 
 	// print the elapsed time
 	fmt.Printf("\nElapsed time (v%d): %s\n", useVersion, elapsed)
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const timerBuckets = 9
+const maxExactDays = 10000000
+
+// Population is the number of fish for each value of their timer
+type Population [timerBuckets]*big.Int
+
+// Matrix is a transition between populations, entry [i][j] being how many
+// fish with timer i a fish with timer j turns into
+type Matrix [timerBuckets][timerBuckets]*big.Int
+
+// newPopulation returns the population of the fish with the given timers
+func newPopulation(initialState []int) (Population, error) {
+	var p Population
+
+	for i := range p {
+		p[i] = new(big.Int)
+	}
+
+	for _, timer := range initialState {
+		if timer < 0 || timer >= timerBuckets {
+			return p, fmt.Errorf("invalid timer %d, expected 0 to %d", timer, timerBuckets-1)
+		}
+
+		p[timer].Add(p[timer], big.NewInt(1))
+	}
+
+	return p, nil
+}
+
+// newMatrix returns a matrix with every entry set to zero
+func newMatrix() Matrix {
+	var m Matrix
+
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = new(big.Int)
+		}
+	}
+
+	return m
+}
+
+// getIdentityMatrix returns the transition of zero days
+func getIdentityMatrix() Matrix {
+	m := newMatrix()
+
+	for i := range m {
+		m[i][i].SetInt64(1)
+	}
+
+	return m
+}
+
+// getDayMatrix returns the transition of a single day: every timer goes down
+// by one, and fish at zero reset to daysToNewFish - 1 and spawn a new fish
+// with the highest timer
+func getDayMatrix() Matrix {
+	m := newMatrix()
+
+	for j := 1; j < timerBuckets; j++ {
+		m[j-1][j].SetInt64(1)
+	}
+
+	m[daysToNewFish-1][0].SetInt64(1)
+	m[timerBuckets-1][0].SetInt64(1)
+
+	return m
+}
+
+// multiply returns the product of two matrices, modulo m if it's not nil
+func (a Matrix) multiply(b Matrix, modulo *big.Int) Matrix {
+	result := newMatrix()
+	product := new(big.Int)
+
+	for i := range result {
+		for k := range a[i] {
+			if a[i][k].Sign() == 0 {
+				continue
+			}
+
+			for j := range result[i] {
+				product.Mul(a[i][k], b[k][j])
+				result[i][j].Add(result[i][j], product)
+			}
+		}
+
+		if modulo != nil {
+			for j := range result[i] {
+				result[i][j].Mod(result[i][j], modulo)
+			}
+		}
+	}
+
+	return result
+}
+
+// power returns the matrix raised to n by repeated squaring, modulo m if
+// it's not nil
+func (a Matrix) power(n uint64, modulo *big.Int) Matrix {
+	result := getIdentityMatrix()
+	base := a
+
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result = result.multiply(base, modulo)
+		}
+
+		if n > 1 {
+			base = base.multiply(base, modulo)
+		}
+	}
+
+	return result
+}
+
+// total returns the number of fish in the population after the transition,
+// modulo m if it's not nil
+func (a Matrix) total(p Population, modulo *big.Int) *big.Int {
+	total := new(big.Int)
+	product := new(big.Int)
+
+	for i := range a {
+		for j := range a[i] {
+			product.Mul(a[i][j], p[j])
+			total.Add(total, product)
+		}
+	}
+
+	if modulo != nil {
+		total.Mod(total, modulo)
+	}
+
+	return total
+}
+
+// getFishAfterDaysV3 returns the number of fish after a set number of days
+// (version 3) by raising the day transition to the number of days, which
+// takes O(log days) matrix products. The exact count grows by about one digit
+// every 27 days, so past maxExactDays only the count modulo m is computed.
+func getFishAfterDaysV3(initialState []int, days uint64, modulo *big.Int) (*big.Int, error) {
+	population, err := newPopulation(initialState)
+	if err != nil {
+		return nil, err
+	}
+
+	if modulo == nil && days > maxExactDays {
+		return nil, fmt.Errorf("the exact number of fish after %d days is too large, count it modulo a number instead", days)
+	}
+
+	if modulo != nil && modulo.Sign() <= 0 {
+		return nil, fmt.Errorf("the modulo must be positive, got %s", modulo)
+	}
+
+	return getDayMatrix().power(days, modulo).total(population, modulo), nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// CostModel is the fuel a crab burns to move a distance along one axis, and
+// how to find the position along that axis where the crabs burn the least.
+type CostModel interface {
+	getCost(distance int) int
+	getOptimalPosition(positions []int) int
+}
+
+// getTotalCost returns the fuel burnt by all the crabs to reach a position
+// along one axis.
+func getTotalCost(model CostModel, positions []int, target int) int {
+	total := 0
+
+	for _, position := range positions {
+		distance := position - target
+		if distance < 0 {
+			distance = -distance
+		}
+
+		total += model.getCost(distance)
+	}
+
+	return total
+}
+
+// getBestCandidate returns the candidate position with the lowest total cost,
+// the lowest one on ties.
+func getBestCandidate(model CostModel, positions []int, candidates []int) int {
+	best := candidates[0]
+	bestCost := getTotalCost(model, positions, best)
+
+	for _, candidate := range candidates[1:] {
+		cost := getTotalCost(model, positions, candidate)
+
+		if cost < bestCost || (cost == bestCost && candidate < best) {
+			best, bestCost = candidate, cost
+		}
+	}
+
+	return best
+}
+
+// floorDiv returns a / b rounded down, for a positive b.
+func floorDiv(a, b int) int {
+	if a < 0 && a%b != 0 {
+		return a/b - 1
+	}
+
+	return a / b
+}
+
+// LinearCost burns one unit of fuel per step. The total is lowest at the
+// median.
+type LinearCost struct{}
+
+func (LinearCost) getCost(distance int) int {
+	return distance
+}
+
+func (LinearCost) getOptimalPosition(positions []int) int {
+	sorted := append([]int{}, positions...)
+	sort.Ints(sorted)
+
+	return sorted[(len(sorted)-1)/2]
+}
+
+// QuadraticCost burns the square of the distance. The total is lowest at the
+// mean, so the closest integers to it are the only candidates.
+type QuadraticCost struct{}
+
+func (QuadraticCost) getCost(distance int) int {
+	return distance * distance
+}
+
+func (q QuadraticCost) getOptimalPosition(positions []int) int {
+	mean := floorDiv(helpers.SumInts(positions...), len(positions))
+
+	return getBestCandidate(q, positions, []int{mean, mean + 1})
+}
+
+// TriangularCost burns one more unit of fuel for every step, n(n+1)/2 in
+// total. The total is lowest within half a step of the mean.
+type TriangularCost struct{}
+
+func (TriangularCost) getCost(distance int) int {
+	return distance * (distance + 1) / 2
+}
+
+func (t TriangularCost) getOptimalPosition(positions []int) int {
+	mean := floorDiv(helpers.SumInts(positions...), len(positions))
+
+	return getBestCandidate(t, positions, []int{mean - 1, mean, mean + 1, mean + 2})
+}
+
+// CostFunc is a cost given as a function of the distance. The cost must be
+// convex in the distance, which makes the total convex in the position, so the
+// optimum is found with a ternary search. A cost that only grows, such as a
+// step function, isn't enough.
+type CostFunc func(distance int) int
+
+// polynomialPrefix starts a user-defined cost on the command line, such as
+// "poly:0,1,0,1" for d + d³.
+const polynomialPrefix = "poly:"
+
+// newPolynomialCost returns the cost c0 + c1·d + c2·d² + …, from the
+// coefficients in increasing order. They can't be negative, so that the cost
+// is convex in the distance.
+func newPolynomialCost(coefficients []int) (CostFunc, error) {
+	if len(coefficients) == 0 {
+		return nil, fmt.Errorf("a polynomial cost needs at least one coefficient")
+	}
+
+	for i, coefficient := range coefficients {
+		if coefficient < 0 {
+			return nil, fmt.Errorf("coefficient %d of the polynomial cost is negative, so the cost may not be convex", i)
+		}
+	}
+
+	return func(distance int) int {
+		cost := 0
+		for i := len(coefficients) - 1; i >= 0; i-- {
+			cost = cost*distance + coefficients[i]
+		}
+
+		return cost
+	}, nil
+}
+
+func (f CostFunc) getCost(distance int) int {
+	return f(distance)
+}
+
+func (f CostFunc) getOptimalPosition(positions []int) int {
+	lowLimit, highLimit := getLowerHigherLimit(positions)
+
+	for highLimit-lowLimit > 2 {
+		thirds := (highLimit - lowLimit) / 3
+		mid1 := lowLimit + thirds
+		mid2 := highLimit - thirds
+
+		dist1 := getTotalCost(f, positions, mid1)
+		dist2 := getTotalCost(f, positions, mid2)
+
+		if dist1 < dist2 {
+			highLimit = mid2
+		} else {
+			lowLimit = mid1
+		}
+	}
+
+	candidates := []int{}
+	for position := lowLimit; position <= highLimit; position++ {
+		candidates = append(candidates, position)
+	}
+
+	return getBestCandidate(f, positions, candidates)
+}
+
+// costModels are the cost models that can be picked on the command line.
+var costModels = map[string]CostModel{
+	"linear":     LinearCost{},
+	"triangular": TriangularCost{},
+	"quadratic":  QuadraticCost{},
+	"cubic": CostFunc(func(distance int) int {
+		return distance * distance * distance
+	}),
+}
+
+// getCostModel returns the cost model with the given name, or the polynomial
+// cost with the given coefficients.
+func getCostModel(name string) (CostModel, error) {
+	if strings.HasPrefix(name, polynomialPrefix) {
+		coefficients, err := helpers.StringToIntArray(strings.TrimPrefix(name, polynomialPrefix), ",")
+		if err != nil {
+			return nil, fmt.Errorf("invalid polynomial cost %q: %w", name, err)
+		}
+
+		return newPolynomialCost(coefficients)
+	}
+
+	model, ok := costModels[name]
+	if !ok {
+		names := []string{}
+		for name := range costModels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("unknown cost model %q, expected one of %s or %s<coefficients>", name, strings.Join(names, ", "), polynomialPrefix)
+	}
+
+	return model, nil
+}
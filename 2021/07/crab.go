@@ -1,8 +1,6 @@
 package main
 
-import (
-	"github.com/joaocarmo/advent-of-code/helpers"
-)
+const numOfAxes = 3
 
 type Crab struct {
 	positionX int
@@ -28,25 +26,19 @@ func (crab *Crab) getZ() int {
 	return crab.positionZ
 }
 
-func (crab *Crab) getDistanceTo(positionX int, positionY int, positionZ int) int {
-	point1 := &helpers.Point{}
-	point1.New(crab.getX(), crab.getY(), crab.getZ())
-
-	point2 := &helpers.Point{}
-	point2.New(positionX, positionY, positionZ)
-
-	return int(helpers.Distance(point1, point2))
+// getCoordinate returns the position of the crab along an axis, 0 being X.
+func (crab *Crab) getCoordinate(axis int) int {
+	return [numOfAxes]int{crab.getX(), crab.getY(), crab.getZ()}[axis]
 }
 
-func (crab *Crab) getWeightedDistanceTo(positionX int, positionY int, positionZ int) int {
-	weightedDistance := 0
-	linearDistance := crab.getDistanceTo(positionX, positionY, positionZ)
+// getFuelTo returns the fuel the crab burns to reach the position, moving
+// along each axis in turn.
+func (crab *Crab) getFuelTo(model CostModel, position [numOfAxes]int) int {
+	fuel := 0
 
-	// for each step in the distance, the weight of a change in position is
-	// increased by 1.
-	for i := 0; i <= linearDistance; i++ {
-		weightedDistance += i
+	for axis := 0; axis < numOfAxes; axis++ {
+		fuel += getTotalCost(model, []int{crab.getCoordinate(axis)}, position[axis])
 	}
 
-	return weightedDistance
+	return fuel
 }
@@ -1,105 +1,120 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
-const verbose = true
 const useVersion = 2
 
-// getCrabPositions parses the text lines to get the crab positions.
-func getCrabPositions(txtlines []string) []*Crab {
-	initialState := helpers.GetInitialState(txtlines)
-	crabs := make([]*Crab, len(initialState))
+var costModelName = flag.String("cost", getDefaultCostModel(), "fuel cost model: linear, triangular, quadratic, cubic or a polynomial in the distance such as poly:0,1,0,1 for d + d³")
+var multipleAxes = flag.Bool("3d", false, "read one crab per line at x,y,z and align them on every axis")
+
+// getCrabPositions parses the text lines to get the crab positions. With
+// multiple axes, each line is a crab at x,y,z, otherwise the lines are lists
+// of horizontal positions.
+func getCrabPositions(txtlines []string, multipleAxes bool) ([]*Crab, error) {
+	if !multipleAxes {
+		initialState, err := helpers.GetInitialState(txtlines)
+		if err != nil {
+			return nil, err
+		}
 
-	for i, initialPosition := range initialState {
-		crabs[i] = &Crab{}
-		crabs[i].new(initialPosition, 0, 0)
-	}
+		crabs := make([]*Crab, len(initialState))
 
-	return crabs
-}
+		for i, initialPosition := range initialState {
+			crabs[i] = &Crab{}
+			crabs[i].new(initialPosition, 0, 0)
+		}
 
-// getLowerHigherLimit returns the lower and higher limit for the given crabs.
-func getLowerHigherLimit(crabs []*Crab) (int, int) {
-	if len(crabs) == 0 {
-		return 0, 0
+		return crabs, nil
 	}
 
-	lowerLimit := crabs[0].getX()
-	higherLimit := crabs[0].getX()
+	crabs := []*Crab{}
 
-	for _, crab := range crabs {
-		if crab.getX() < lowerLimit {
-			lowerLimit = crab.getX()
+	for i, line := range txtlines {
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
 
-		if crab.getX() > higherLimit {
-			higherLimit = crab.getX()
+		fields := helpers.SplitFields(line, ",")
+		if len(fields) > numOfAxes {
+			return nil, helpers.NewParseError(i+1, fields[numOfAxes].Col, line, "expected at most %d coordinates, got %d", numOfAxes, len(fields))
 		}
+
+		var coordinates [numOfAxes]int
+		for axis, field := range fields {
+			coordinate, err := field.Int()
+			if err != nil {
+				return nil, helpers.AtLine(err, i+1, line)
+			}
+			coordinates[axis] = coordinate
+		}
+
+		crab := &Crab{}
+		crab.new(coordinates[0], coordinates[1], coordinates[2])
+		crabs = append(crabs, crab)
+	}
+
+	return crabs, nil
+}
+
+// getLowerHigherLimit returns the lower and higher limit for the given
+// positions.
+func getLowerHigherLimit(positions []int) (int, int) {
+	if len(positions) == 0 {
+		return 0, 0
 	}
 
-	return lowerLimit, higherLimit
+	return helpers.MinMax(positions)
 }
 
-// getDistance returns the distance between the given crabs and the given
+// getDistance returns the fuel burnt by the given crabs to reach the given
 // position.
-func getDistance(crabs []*Crab, positionX int) int {
+func getDistance(crabs []*Crab, model CostModel, position [numOfAxes]int) int {
 	var distance int
 
 	for _, crab := range crabs {
-		if useVersion == 2 {
-			distance += crab.getWeightedDistanceTo(positionX, 0, 0)
-		} else {
-			distance += crab.getDistanceTo(positionX, 0, 0)
-		}
+		distance += crab.getFuelTo(model, position)
 	}
 
 	return distance
 }
 
 // getOptimalPositionAndFuel returns the optimal position and fuel consumption
-// for the crabs. Considering only the horizontal axis.
-func getOptimalPositionAndFuel(crabs []*Crab) (int, int) {
-	var optimalPosition int
-	var fuelConsumption int
-
-	lowLimit, highLimit := getLowerHigherLimit(crabs)
-
-	for {
-		// use a ternary search algorithm to find the optimal position
-		thirds := (highLimit - lowLimit) / 3
-		mid1 := lowLimit + thirds
-		mid2 := highLimit - thirds
-
-		dist1 := getDistance(crabs, mid1)
-		dist2 := getDistance(crabs, mid2)
-
-		if dist1 < dist2 {
-			highLimit = mid2
-		} else {
-			lowLimit = mid1
-		}
+// for the crabs. Crabs move along one axis at a time, so the fuel along each
+// axis is independent and each one is optimized separately.
+func getOptimalPositionAndFuel(crabs []*Crab, model CostModel) ([numOfAxes]int, int) {
+	var optimalPosition [numOfAxes]int
 
-		if verbose {
-			// print the results
-			fmt.Printf("Low limit: %d\n", lowLimit)
-			fmt.Printf("High limit: %d\n", highLimit)
-			fmt.Printf("Distance1: %d\n", dist1)
-			fmt.Printf("Distance2: %d\n", dist2)
-		}
+	if len(crabs) == 0 {
+		return optimalPosition, 0
+	}
 
-		if highLimit-lowLimit <= 2 {
-			break
+	for axis := 0; axis < numOfAxes; axis++ {
+		positions := make([]int, len(crabs))
+		for i, crab := range crabs {
+			positions[i] = crab.getCoordinate(axis)
 		}
+
+		optimalPosition[axis] = model.getOptimalPosition(positions)
 	}
 
-	optimalPosition = lowLimit + (highLimit-lowLimit)/2
-	fuelConsumption = getDistance(crabs, optimalPosition)
+	return optimalPosition, getDistance(crabs, model, optimalPosition)
+}
+
+// getDefaultCostModel returns the cost model of the chosen version of the
+// puzzle.
+func getDefaultCostModel() string {
+	if useVersion == 2 {
+		return "triangular"
+	}
 
-	return optimalPosition, fuelConsumption
+	return "linear"
 }
 
 // main is the entry point for the application.
@@ -109,13 +124,27 @@ func main() {
 	filename := args[0]
 	txtlines := helpers.ReadFile(filename)
 
+	model, err := getCostModel(*costModelName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid cost model: %s\n", err)
+		os.Exit(1)
+	}
+
 	// parse the file to get the crab positions
-	crabs := getCrabPositions(txtlines)
+	crabs, err := getCrabPositions(txtlines, *multipleAxes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing crabs: %s\n", err)
+		os.Exit(1)
+	}
 
 	// get the optimal position
-	optimalPosition, fuelConsumption := getOptimalPositionAndFuel(crabs)
+	optimalPosition, fuelConsumption := getOptimalPositionAndFuel(crabs, model)
 
 	// print the results
-	fmt.Printf("Optimal position: %d\n", optimalPosition)
+	if *multipleAxes {
+		fmt.Printf("Optimal position: (%d, %d, %d)\n", optimalPosition[0], optimalPosition[1], optimalPosition[2])
+	} else {
+		fmt.Printf("Optimal position: %d\n", optimalPosition[0])
+	}
 	fmt.Printf("Fuel consumption: %d\n", fuelConsumption)
 }
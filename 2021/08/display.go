@@ -2,145 +2,148 @@ package main
 
 import (
 	"fmt"
+	"math/bits"
+	"sort"
 	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
-type SevenSegmentDisplay struct {
-	top         string
-	topLeft     string
-	topRight    string
-	middle      string
-	bottom      string
-	bottomLeft  string
-	bottomRight string
+const maxSegments = 64
+
+// sevenSegmentDigits are the digits of a seven-segment display, with the
+// segments named from a (top) to g (bottom) like in the puzzle.
+var sevenSegmentDigits = []string{
+	"0 abcefg",
+	"1 cf",
+	"2 acdeg",
+	"3 acdfg",
+	"4 bcdf",
+	"5 abdfg",
+	"6 abdefg",
+	"7 acf",
+	"8 abcdefg",
+	"9 abcdfg",
 }
 
-func (s SevenSegmentDisplay) getSingalForNum(num int) string {
-	var signal string
-
-	switch num {
-	case 0:
-		signal = s.top + s.topLeft + s.topRight + s.bottom + s.bottomLeft + s.bottomRight
-	case 1:
-		signal = s.topRight + s.bottomRight
-	case 2:
-		signal = s.top + s.topRight + s.middle + s.bottomLeft + s.bottom
-	case 3:
-		signal = s.top + s.topRight + s.middle + s.bottom + s.bottomRight
-	case 4:
-		signal = s.topLeft + s.middle + s.topRight + s.bottomRight
-	case 5:
-		signal = s.top + s.topLeft + s.middle + s.bottom + s.bottomRight
-	case 6:
-		signal = s.top + s.topLeft + s.middle + s.bottom + s.bottomRight + s.bottomLeft
-	case 7:
-		signal = s.top + s.topRight + s.bottomRight
-	case 8:
-		signal = s.top + s.topLeft + s.topRight + s.middle + s.bottom + s.bottomLeft + s.bottomRight
-	case 9:
-		signal = s.top + s.topLeft + s.topRight + s.middle + s.bottom + s.bottomRight
-	}
+// sevenSegmentHex are the hexadecimal digits of a seven-segment display.
+var sevenSegmentHex = append(append([]string{}, sevenSegmentDigits...),
+	"A abcdef",
+	"b bdefg",
+	"C abeg",
+	"d cdefg",
+	"E abdeg",
+	"F abde",
+)
 
-	return helpers.SortString(signal)
+// builtinDisplays are the displays that can be picked by name.
+var builtinDisplays = map[string][]string{
+	"seven": sevenSegmentDigits,
+	"hex":   sevenSegmentHex,
 }
 
-func (s SevenSegmentDisplay) getNumForSignal(signal string) int {
-	var num int
-	sortedSignal := helpers.SortString(signal)
-
-	switch sortedSignal {
-	case s.getSingalForNum(0):
-		num = 0
-	case s.getSingalForNum(1):
-		num = 1
-	case s.getSingalForNum(2):
-		num = 2
-	case s.getSingalForNum(3):
-		num = 3
-	case s.getSingalForNum(4):
-		num = 4
-	case s.getSingalForNum(5):
-		num = 5
-	case s.getSingalForNum(6):
-		num = 6
-	case s.getSingalForNum(7):
-		num = 7
-	case s.getSingalForNum(8):
-		num = 8
-	case s.getSingalForNum(9):
-		num = 9
-	}
+// Glyph is a symbol shown on a display and the segments lit to show it.
+type Glyph struct {
+	symbol   string
+	segments uint64
+}
 
-	return num
+// Display is a set of glyphs drawn with a number of segments, which are named
+// with consecutive letters from a.
+type Display struct {
+	numOfSegments int
+	glyphs        []Glyph
+	glyphsBySize  map[int][]Glyph
+	bySegments    map[uint64]Glyph
 }
 
-func (s *SevenSegmentDisplay) toString() string {
-	output := fmt.Sprintf(" %s\n", strings.Repeat(s.top, 4))
-	output += fmt.Sprintf("%s    %s\n", s.topLeft, s.topRight)
-	output += fmt.Sprintf("%s    %s\n", s.topLeft, s.topRight)
-	output += fmt.Sprintf(" %s\n", strings.Repeat(s.middle, 4))
-	output += fmt.Sprintf("%s    %s\n", s.bottomLeft, s.bottomRight)
-	output += fmt.Sprintf("%s    %s\n", s.bottomLeft, s.bottomRight)
-	output += fmt.Sprintf(" %s\n", strings.Repeat(s.bottom, 4))
+// getSegmentMask returns the bitmask of the segments or wires named by the
+// letters in the string.
+func getSegmentMask(letters string) (uint64, error) {
+	var mask uint64
+
+	for _, letter := range letters {
+		bit := int(letter - 'a')
+		if bit < 0 || bit >= maxSegments {
+			return 0, fmt.Errorf("invalid segment %q", letter)
+		}
 
-	return output
+		mask |= 1 << bit
+	}
+
+	return mask, nil
 }
 
-func (ssd *SevenSegmentDisplay) inferFromSingals(signals map[int][]string) {
-	// Start by assuming the topRight and bottomRight from 1
-	signalsRight := strings.Split(signals[1][0], "")
-	ssd.topRight = signalsRight[0]
-	ssd.bottomRight = signalsRight[1]
-
-	// Find the top using 1 and 7
-	ssd.top = helpers.StringDiff(signals[1][0], signals[7][0])[0]
-
-	// Start by assuming the topLeft and middle from 1 and 4
-	signalsTopLeftMiddle := helpers.StringDiff(signals[1][0], signals[4][0])
-	ssd.topLeft = signalsTopLeftMiddle[0]
-	ssd.middle = signalsTopLeftMiddle[1]
-
-	// Start by assuming the bottomLeft and bottom from 4, 7, and 8
-	signalsBottomLeftBottom := helpers.StringDiff(signals[4][0]+signals[7][0], signals[8][0])
-	ssd.bottomLeft = signalsBottomLeftBottom[0]
-	ssd.bottom = signalsBottomLeftBottom[1]
-
-	// We now have assigned a unique signal to a segment of the display, but we
-	// are still unsure about: topRight/bottomRight, topLeft/middle, and
-	// bottomLeft/bottom
-
-	// Find the topLeft and middle from 0, middle should appear twice and
-	// topLeft three times
-	numTopLeft := getNumOfOccurrences(ssd.topLeft, signals[0])
-	numMiddle := getNumOfOccurrences(ssd.middle, signals[0])
-
-	if numMiddle > numTopLeft {
-		tmpTopLeft := ssd.topLeft
-		ssd.topLeft = ssd.middle
-		ssd.middle = tmpTopLeft
+// newDisplay returns the display defined by lines such as "7 acf", each being
+// a symbol and the segments lit to show it. Empty lines and lines starting
+// with # are ignored.
+func newDisplay(definition []string) (*Display, error) {
+	d := &Display{
+		glyphsBySize: make(map[int][]Glyph),
+		bySegments:   make(map[uint64]Glyph),
 	}
 
-	// Find the topRight and bottomRight from 0, topRight should appear twice
-	// and bottomRight three times
-	numTopRight := getNumOfOccurrences(ssd.topRight, signals[0])
-	numBottomRight := getNumOfOccurrences(ssd.bottomRight, signals[0])
+	for i, line := range definition {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if len(fields) != 2 {
+			return nil, helpers.NewParseError(i+1, 0, line, "expected a symbol and its segments")
+		}
+
+		segments, err := getSegmentMask(fields[1])
+		if err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
+		}
+
+		if other, ok := d.bySegments[segments]; ok {
+			return nil, helpers.NewParseError(i+1, 0, line, "%s lights the same segments as %s", fields[0], other.symbol)
+		}
+
+		glyph := Glyph{fields[0], segments}
+		d.glyphs = append(d.glyphs, glyph)
+		d.bySegments[segments] = glyph
 
-	if numTopRight > numBottomRight {
-		tmpTopRight := ssd.topRight
-		ssd.topRight = ssd.bottomRight
-		ssd.bottomRight = tmpTopRight
+		size := bits.OnesCount64(segments)
+		d.glyphsBySize[size] = append(d.glyphsBySize[size], glyph)
+
+		if width := 64 - bits.LeadingZeros64(segments); width > d.numOfSegments {
+			d.numOfSegments = width
+		}
+	}
+
+	if len(d.glyphs) == 0 {
+		return nil, fmt.Errorf("the display has no glyphs")
+	}
+
+	return d, nil
+}
+
+// getBuiltinDisplay returns the builtin display with the given name.
+func getBuiltinDisplay(name string) (*Display, error) {
+	definition, ok := builtinDisplays[name]
+	if !ok {
+		names := []string{}
+		for name := range builtinDisplays {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("unknown display %q, expected one of %s or a file", name, strings.Join(names, ", "))
 	}
 
-	// Find the bottomLeft and bottom from 0, bottomLeft should appear twice and
-	// bottom three times
-	numBottomLeft := getNumOfOccurrences(ssd.bottomLeft, signals[0])
-	numBottom := getNumOfOccurrences(ssd.bottom, signals[0])
+	return newDisplay(definition)
+}
 
-	if numBottomLeft > numBottom {
-		tmpBottomLeft := ssd.bottomLeft
-		ssd.bottomLeft = ssd.bottom
-		ssd.bottom = tmpBottomLeft
+// getNumOfSignalsToGlyph returns the number of segments lit by each glyph.
+func (d *Display) getNumOfSignalsToGlyph() map[int]int {
+	numOfSignals := make(map[int]int, len(d.glyphs))
+
+	for i, glyph := range d.glyphs {
+		numOfSignals[i] = bits.OnesCount64(glyph.segments)
 	}
+
+	return numOfSignals
 }
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -10,30 +12,11 @@ import (
 
 const verbose = false
 
-var numOfSignalsToDigit = map[int]int{
-	0: 6,
-	1: 2, // unique
-	2: 5,
-	3: 5,
-	4: 4, // unique
-	5: 5,
-	6: 6,
-	7: 3, // unique
-	8: 7, // unique
-	9: 6,
-}
-
-// getNumOfOccurrences returns the number of occurrences of a signal in an array
-// of signals.
-func getNumOfOccurrences(signal string, signals []string) int {
-	count := 0
+var displayName = flag.String("display", "seven", "builtin display (seven, hex) or a file defining one glyph per line as \"symbol segments\"")
 
-	for _, s := range signals {
-		count += strings.Count(s, signal)
-	}
-
-	return count
-}
+// numOfSignalsToDigit is the number of segments lit by each glyph of the
+// display, the glyphs being numbered in the order they are defined
+var numOfSignalsToDigit map[int]int
 
 // printOutputMap prints the output map.
 func printOutputMap(output map[int]map[string][]int) {
@@ -52,10 +35,12 @@ func printOutputMap(output map[int]map[string][]int) {
 	}
 }
 
-// parseInput parses the input into a map of signal patterns and output values.
-func parseInput(lines []string) ([][]string, [][]string) {
-	allSignals := make([][]string, len(lines))
-	allOutput := make([][]string, len(lines))
+// parseInput parses the input into a map of signal patterns and output values,
+// and the number of the line each entry is on.
+func parseInput(lines []string) ([][]string, [][]string, []int, error) {
+	allSignals := [][]string{}
+	allOutput := [][]string{}
+	lineNums := []int{}
 
 	for i, line := range lines {
 		if line == "" {
@@ -64,21 +49,26 @@ func parseInput(lines []string) ([][]string, [][]string) {
 
 		// split the line into signal and output
 		result := strings.Split(line, "|")
+		if len(result) != 2 {
+			return nil, nil, nil, helpers.NewParseError(i+1, 0, line, "expected signal patterns and output values separated by |")
+		}
+
 		signalPatterns := strings.TrimSpace(result[0])
 		outputValues := strings.TrimSpace(result[1])
 
 		// split the signal patterns into an array
-		signals := strings.Split(signalPatterns, " ")
+		signals := strings.Fields(signalPatterns)
 
 		// split the output values into an array
-		output := strings.Split(outputValues, " ")
+		output := strings.Fields(outputValues)
 
 		// add the signal and output to the map
-		allSignals[i] = signals
-		allOutput[i] = output
+		allSignals = append(allSignals, signals)
+		allOutput = append(allOutput, output)
+		lineNums = append(lineNums, i+1)
 	}
 
-	return allSignals, allOutput
+	return allSignals, allOutput, lineNums, nil
 }
 
 // inferDigitsFromNumOfSignals takes a number of signals and returns the digit.
@@ -189,76 +179,53 @@ func countLenPerLine(output map[int]map[string][]int) int {
 	return numOfUniqueSignals
 }
 
-// getSignalsContaining returns the signals containing a given possible number.
-func getSignalsContaining(lineDigits map[string][]int, number int) []string {
-	var signalsContaining []string
-
-	// loop through the digits
-	for signal, digits := range lineDigits {
-		if helpers.IntArrayContains(digits, number) {
-			signalsContaining = append(signalsContaining, signal)
-		}
+// getDisplay returns the builtin display with the given name, or the one
+// defined in the file with that name.
+func getDisplay(name string) (*Display, error) {
+	if _, ok := builtinDisplays[name]; ok {
+		return getBuiltinDisplay(name)
 	}
 
-	return signalsContaining
-}
-
-// getSevenSegmentDisplaysFromSignals gets the 7-segment display decoded from the
-// signals.
-func getSevenSegmentDisplaysFromSignals(signals [][]string) []*SevenSegmentDisplay {
-	ssdArr := make([]*SevenSegmentDisplay, len(signals))
-
-	possibleOutputDigits := getPossibleOutputDigits(signals, true)
-	printOutputMap(possibleOutputDigits)
-
-	// loop through the lines
-	for line, lineDigits := range possibleOutputDigits {
-		singalsContaining := make(map[int][]string, len(lineDigits))
-
-		for i := 0; i < len(lineDigits); i++ {
-			singalsContaining[i] = getSignalsContaining(lineDigits, i)
-
-			if verbose {
-				fmt.Println(i, singalsContaining[i])
-			}
-		}
-
-		ssd := &SevenSegmentDisplay{}
-		ssd.inferFromSingals(singalsContaining)
-		ssdArr[line] = ssd
+	if _, err := os.Stat(name); err != nil {
+		return getBuiltinDisplay(name)
 	}
 
-	return ssdArr
+	return newDisplay(helpers.ReadFile(name))
 }
 
-// getDecodedOutput gets the decoded output from the signals.
-func getDecodedOutput(output [][]string, ssdArr []*SevenSegmentDisplay) [][]int {
-	var decodedOutput [][]int
+// getDecodedOutput decodes the output of every line, reporting the lines that
+// cannot be decoded instead.
+func getDecodedOutput(display *Display, txtlines []string, lineNums []int, signals [][]string, output [][]string) []string {
+	var decodedOutput []string
 
-	for line, signals := range output {
-		decodedLine := []int{}
-
-		for _, signal := range signals {
-			ssd := ssdArr[line]
-			decodedLine = append(decodedLine, ssd.getNumForSignal(signal))
+	for line := range signals {
+		decoded, err := display.decodeLine(signals[line], output[line])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, helpers.AtLine(err, lineNums[line], txtlines[lineNums[line]-1]))
+			continue
 		}
 
-		decodedOutput = append(decodedOutput, decodedLine)
+		decodedOutput = append(decodedOutput, decoded)
 	}
 
 	return decodedOutput
 }
 
-// decodedOutputToNumbers converts the decoded output to numbers.
-func decodedOutputToNumbers(decodedOutput [][]int) []int {
+// decodedOutputToNumbers converts the decoded output to numbers, or reports
+// false if some output is not a number.
+func decodedOutputToNumbers(decodedOutput []string) ([]int, bool) {
 	var numbers []int
 
 	for _, digits := range decodedOutput {
-		num, _ := strconv.Atoi(helpers.IntArrayToString(digits, ""))
+		num, err := strconv.Atoi(digits)
+		if err != nil {
+			return nil, false
+		}
+
 		numbers = append(numbers, num)
 	}
 
-	return numbers
+	return numbers, true
 }
 
 // sumDecodedNumbers sums the decoded numbers.
@@ -279,8 +246,20 @@ func main() {
 	filename := args[0]
 	txtlines := helpers.ReadFile(filename)
 
+	// get the display the signals are meant for
+	display, err := getDisplay(*displayName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid display: %s\n", err)
+		os.Exit(1)
+	}
+	numOfSignalsToDigit = display.getNumOfSignalsToGlyph()
+
 	// parse the file into signal patterns and output values
-	signals, output := parseInput(txtlines)
+	signals, output, lineNums, err := parseInput(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing signals: %s\n", err)
+		os.Exit(1)
+	}
 
 	// infer the digits from the number of signals for the output
 	possibleOutputDigits := getPossibleOutputDigits(output, false)
@@ -296,35 +275,24 @@ func main() {
 	printOutputMap(numOfSingleDigits)
 	fmt.Printf("Number of unique signals with single possible digits: %d\n\n", countLenPerLine(numOfSingleDigits))
 
-	// get the 7-segment display from the singnals
-	ssdArr := getSevenSegmentDisplaysFromSignals(signals)
-
-	// get the decoded output from the 7-segment displays
-	decodedOutput := getDecodedOutput(output, ssdArr)
+	// solve the wiring of every line to decode the output
+	decodedOutput := getDecodedOutput(display, txtlines, lineNums, signals, output)
 
 	if verbose {
 		for i, signal := range signals {
-			fmt.Printf("%v | %v | %v\n\n", signal, output[i], ssdArr[i])
+			fmt.Printf("%v | %v\n\n", signal, output[i])
 		}
 	}
 
-	decodedNumbers := decodedOutputToNumbers(decodedOutput)
+	decodedNumbers, ok := decodedOutputToNumbers(decodedOutput)
+	if !ok {
+		fmt.Printf("Decoded output: %v\n", decodedOutput)
+		return
+	}
 
 	sumOfNumbers := sumDecodedNumbers(decodedNumbers)
 
 	// print the decoded output
 	fmt.Printf("Decoded output: %v\n\n", decodedNumbers)
 	fmt.Printf("Sum of output: %d\n", sumOfNumbers)
-
-	// print the 7-segment displays
-	if verbose {
-		fmt.Println()
-		for line, ssd := range ssdArr {
-			fmt.Printf("Line %d, 7-segment display:\n%s\n", line, ssd.toString())
-			for i := 0; i < 10; i++ {
-				fmt.Printf("%d: %s\n", i, ssd.getSingalForNum(i))
-			}
-			fmt.Println()
-		}
-	}
 }
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// ErrUnsatisfiable is returned when no wiring explains the signals.
+var ErrUnsatisfiable = errors.New("no wiring matches the signals")
+
+// ErrAmbiguous is returned when different wirings decode the output
+// differently.
+var ErrAmbiguous = errors.New("the signals match several wirings")
+
+// Wiring is the segment lit by each wire, or -1 for wires never seen.
+type Wiring []int
+
+// apply returns the segments lit by the wires.
+func (w Wiring) apply(wires uint64) uint64 {
+	var segments uint64
+
+	for ; wires != 0; wires &= wires - 1 {
+		segments |= 1 << w[bits.TrailingZeros64(wires)]
+	}
+
+	return segments
+}
+
+// decode returns the symbols shown by the wires of every pattern.
+func (d *Display) decode(w Wiring, patterns []uint64) (string, error) {
+	var sb strings.Builder
+
+	for _, pattern := range patterns {
+		glyph, ok := d.bySegments[w.apply(pattern)]
+		if !ok {
+			return "", fmt.Errorf("%w: no glyph for wires %s", ErrUnsatisfiable, getWireNames(pattern))
+		}
+
+		sb.WriteString(glyph.symbol)
+	}
+
+	return sb.String(), nil
+}
+
+// getWireNames returns the letters of the wires in a pattern.
+func getWireNames(pattern uint64) string {
+	var sb strings.Builder
+
+	for ; pattern != 0; pattern &= pattern - 1 {
+		sb.WriteRune(rune('a' + bits.TrailingZeros64(pattern)))
+	}
+
+	return sb.String()
+}
+
+// solver searches for wirings that light a glyph for every pattern. Each
+// wire is a bit, and so is each segment.
+type solver struct {
+	display    *Display
+	patterns   []uint64
+	candidates []uint64
+	wiring     Wiring
+	assigned   uint64
+	used       uint64
+}
+
+// isConsistent checks that, with the wires assigned so far, every pattern
+// can still become a glyph of the same size: one lighting the segments of
+// the pattern's assigned wires, and none of the other assigned wires.
+func (s *solver) isConsistent() bool {
+	for _, pattern := range s.patterns {
+		lit := s.wiring.apply(pattern & s.assigned)
+		unlit := s.wiring.apply(^pattern & s.assigned)
+		possible := false
+
+		for _, glyph := range s.display.glyphsBySize[bits.OnesCount64(pattern)] {
+			if glyph.segments&lit == lit && glyph.segments&unlit == 0 {
+				possible = true
+				break
+			}
+		}
+
+		if !possible {
+			return false
+		}
+	}
+
+	return true
+}
+
+// search assigns the remaining wires in order, calling found with every
+// complete wiring until it returns false. It returns false once stopped.
+func (s *solver) search(order []int, found func(w Wiring) bool) bool {
+	if len(order) == 0 {
+		return found(s.wiring)
+	}
+
+	wire := order[0]
+	bit := uint64(1) << wire
+
+	for options := s.candidates[wire] &^ s.used; options != 0; options &= options - 1 {
+		segment := bits.TrailingZeros64(options)
+
+		s.wiring[wire] = segment
+		s.assigned |= bit
+		s.used |= 1 << segment
+
+		keepGoing := true
+		if s.isConsistent() {
+			keepGoing = s.search(order[1:], found)
+		}
+
+		s.wiring[wire] = -1
+		s.assigned &^= bit
+		s.used &^= 1 << segment
+
+		if !keepGoing {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getPatterns returns the bitmasks of the wires in the signals.
+func getPatterns(signals []string) ([]uint64, error) {
+	patterns := make([]uint64, len(signals))
+
+	for i, signal := range signals {
+		pattern, err := getSegmentMask(signal)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns[i] = pattern
+	}
+
+	return patterns, nil
+}
+
+// decodeLine finds how the wires are connected to the segments from all the
+// signals of a line and returns the symbols shown by the output. It fails if
+// no wiring explains the signals, or if wirings that do disagree on the
+// output.
+func (d *Display) decodeLine(signals []string, output []string) (string, error) {
+	patterns, err := getPatterns(append(append([]string{}, signals...), output...))
+	if err != nil {
+		return "", err
+	}
+
+	outputPatterns := patterns[len(signals):]
+
+	s := &solver{
+		display:    d,
+		candidates: make([]uint64, maxSegments),
+		wiring:     make(Wiring, maxSegments),
+	}
+
+	allSegments := uint64(1)<<d.numOfSegments - 1
+	for wire := range s.candidates {
+		s.candidates[wire] = allSegments
+		s.wiring[wire] = -1
+	}
+
+	// a wire in a pattern can only light segments of the glyphs that have as
+	// many segments as the pattern has wires
+	seen := map[uint64]bool{}
+	var wires uint64
+	for _, pattern := range patterns {
+		if seen[pattern] {
+			continue
+		}
+		seen[pattern] = true
+		s.patterns = append(s.patterns, pattern)
+		wires |= pattern
+
+		var possible uint64
+		for _, glyph := range d.glyphsBySize[bits.OnesCount64(pattern)] {
+			possible |= glyph.segments
+		}
+
+		for rest := pattern; rest != 0; rest &= rest - 1 {
+			s.candidates[bits.TrailingZeros64(rest)] &= possible
+		}
+	}
+
+	if bits.OnesCount64(wires) > d.numOfSegments {
+		return "", fmt.Errorf("%w: %d wires for %d segments", ErrUnsatisfiable, bits.OnesCount64(wires), d.numOfSegments)
+	}
+
+	// the most constrained wires first prune the most
+	order := []int{}
+	for rest := wires; rest != 0; rest &= rest - 1 {
+		order = append(order, bits.TrailingZeros64(rest))
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return bits.OnesCount64(s.candidates[order[i]]) < bits.OnesCount64(s.candidates[order[j]])
+	})
+
+	decodings := []string{}
+	s.search(order, func(w Wiring) bool {
+		decoded, err := d.decode(w, outputPatterns)
+		if err != nil || (len(decodings) > 0 && decodings[0] == decoded) {
+			return true
+		}
+
+		decodings = append(decodings, decoded)
+		return len(decodings) < 2
+	})
+
+	switch len(decodings) {
+	case 0:
+		return "", ErrUnsatisfiable
+	case 1:
+		return decodings[0], nil
+	}
+
+	return "", fmt.Errorf("%w: the output reads %s or %s", ErrAmbiguous, decodings[0], decodings[1])
+}
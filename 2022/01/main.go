@@ -1,131 +1,152 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
-// distributeSnacksEachElfIsCarrying distributes the snacks each elf is carrying.
-func distributeSnacksEachElfIsCarrying(input []string) [][]int {
-	// Each elf has a list of snacks per line
-	// Blank lines separate each elf
-	var elfs [][]int
-	var elf []int
-
-	// Split the input into elfs
-	for _, line := range input {
-		snack, err := strconv.Atoi(line)
-
-		if err != nil {
-			// Blank line, new elf
-			elfs = append(elfs, elf)
-			elf = []int{}
-		} else {
-			elf = append(elf, snack)
+var top = flag.Int("top", 3, "number of elfs with the most calories in part two")
+var statsFormat = flag.String("stats", "", "print the statistics of each elf as a \"table\" or \"json\" (with json, the answers go to stderr)")
+
+// forEachElf streams the snacks each elf is carrying, calling the function
+// with the snacks of one elf at a time. Each elf has a list of snacks per
+// line, and blank lines separate each elf.
+func forEachElf(r io.Reader, fn func(elf int, snacks []int)) error {
+	scanner := bufio.NewScanner(r)
+	elf := 0
+	var snacks []int
+
+	flush := func() {
+		// Skip the elfs without snacks
+		if len(snacks) > 0 {
+			elf++
+			fn(elf, snacks)
+			snacks = snacks[:0]
 		}
 	}
 
-	// Add the last elf, if not empty
-	if len(elf) > 0 {
-		elfs = append(elfs, elf)
-	}
-
-	return elfs
-}
-
-// calculateTotalNumberOfCaloriesEachElfIsCarrying calculates the total number of calories each elf is carrying.
-func calculateTotalNumberOfCaloriesEachElfIsCarrying(input []string) []int {
-	snacksEachElfIsCarrying := distributeSnacksEachElfIsCarrying(input)
-
-	var totalNumberOfCaloriesEachElfIsCarrying []int
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
 
-	for _, elf := range snacksEachElfIsCarrying {
-		var totalNumberOfCalories int
+		if line == "" {
+			// Blank line, new elf
+			flush()
+			continue
+		}
 
-		for _, snack := range elf {
-			totalNumberOfCalories += snack
+		snack, err := strconv.Atoi(line)
+		if err != nil {
+			return helpers.NewParseError(i, 0, scanner.Text(), "invalid number of calories %q", line)
 		}
 
-		totalNumberOfCaloriesEachElfIsCarrying = append(totalNumberOfCaloriesEachElfIsCarrying, totalNumberOfCalories)
+		snacks = append(snacks, snack)
 	}
 
-	return totalNumberOfCaloriesEachElfIsCarrying
+	// Add the last elf, if not empty
+	flush()
+
+	return scanner.Err()
 }
 
-// findTheElfWithTheMostCalories finds the elf with the most calories.
-func findTheElfWithTheMostCalories(input []int) (int, int) {
-	var elfWithTheMostCalories int
-	var mostCalories int
+// formatElfs returns the numbers of the elfs, like "#4 #3 #5".
+func formatElfs(elfs []Ranked) string {
+	numbers := make([]string, len(elfs))
 
-	for elf, calories := range input {
-		if calories > mostCalories {
-			elfWithTheMostCalories = elf
-			mostCalories = calories
-		}
+	for i, r := range elfs {
+		numbers[i] = fmt.Sprintf("#%d", r.elf)
 	}
 
-	return elfWithTheMostCalories, mostCalories
+	return strings.Join(numbers, " ")
 }
 
-// findTheTopThreeElfsWithTheMostCalories finds the top three elfs with the most calories.
-func findTheTopThreeElfsWithTheMostCalories(input []int) ([]int, int) {
-	var topThreeElfsWithTheMostCalories []int
-	topThreeElfsMostCalories := 0
-
-	// Copy the input
-	elfsWithCalories := make([]int, len(input))
-	copy(elfsWithCalories, input)
-
-	// Find the first elf with the most calories
-	elfWithTheMostCalories, mostCalories := findTheElfWithTheMostCalories(elfsWithCalories)
-	topThreeElfsWithTheMostCalories = append(topThreeElfsWithTheMostCalories, elfWithTheMostCalories)
-	topThreeElfsMostCalories += mostCalories
+// main is the entry point for the application.
+func main() {
+	// read the file
+	args := helpers.ReadArguments()
+	filename := args[0]
 
-	// Remove the first elf from the list
-	elfsWithCalories[elfWithTheMostCalories] = 0
+	if *top < 1 {
+		fmt.Fprintf(os.Stderr, "the top must have at least 1 elf, got %d\n", *top)
+		os.Exit(1)
+	}
 
-	// Find the second elf with the most calories
-	elfWithTheMostCalories, mostCalories = findTheElfWithTheMostCalories(elfsWithCalories)
-	topThreeElfsWithTheMostCalories = append(topThreeElfsWithTheMostCalories, elfWithTheMostCalories)
-	topThreeElfsMostCalories += mostCalories
+	if *statsFormat != "" && *statsFormat != "table" && *statsFormat != "json" {
+		fmt.Fprintf(os.Stderr, "unknown statistics format %q, expected table or json\n", *statsFormat)
+		os.Exit(1)
+	}
 
-	// Remove the second elf from the list
-	elfsWithCalories[elfWithTheMostCalories] = 0
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed opening file: %s\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
 
-	// Find the third elf with the most calories
-	elfWithTheMostCalories, mostCalories = findTheElfWithTheMostCalories(elfsWithCalories)
-	topThreeElfsWithTheMostCalories = append(topThreeElfsWithTheMostCalories, elfWithTheMostCalories)
-	topThreeElfsMostCalories += mostCalories
+	// process the file one elf at a time
+	topOne := newTopK(1)
+	topK := newTopK(*top)
+	var stats []ElfStats
 
-	return topThreeElfsWithTheMostCalories, topThreeElfsMostCalories
-}
+	err = forEachElf(file, func(elf int, snacks []int) {
+		calories := helpers.SumInts(snacks...)
+		topOne.add(elf, calories)
+		topK.add(elf, calories)
 
-// main is the entry point for the application.
-func main() {
-	// read the file
-	args := helpers.ReadArguments()
-	filename := args[0]
-	txtlines := helpers.ReadFile(filename)
+		if *statsFormat != "" {
+			stats = append(stats, getElfStats(elf, snacks))
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the snacks: %s\n", err)
+		os.Exit(1)
+	}
 
-	// process the file
-	totalNumberOfCaloriesEachElfIsCarrying := calculateTotalNumberOfCaloriesEachElfIsCarrying(txtlines)
+	// the answers go to stderr when the statistics are JSON, so stdout can be
+	// piped to other tools
+	answers := io.Writer(os.Stdout)
+	if *statsFormat == "json" {
+		answers = os.Stderr
+	}
 
 	// part 1
-	elfWithTheMostCalories, mostCalories := findTheElfWithTheMostCalories(totalNumberOfCaloriesEachElfIsCarrying)
-	fmt.Printf(
-		"[Part One] The answer is: %7d\t(elf  #%d)\n",
+	elfWithTheMostCalories, mostCalories := topOne.getTop()
+	if len(elfWithTheMostCalories) == 0 {
+		fmt.Fprintln(os.Stderr, "there are no elfs carrying snacks")
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(
+		answers,
+		"[Part One] The answer is: %7d\t(elf  %s)\n",
 		mostCalories,
-		elfWithTheMostCalories + 1,
+		formatElfs(elfWithTheMostCalories),
 	)
 
 	// part 2
-	topThreeElfsWithTheMostCalories, topThreeElfsMostCalories := findTheTopThreeElfsWithTheMostCalories(totalNumberOfCaloriesEachElfIsCarrying)
-	fmt.Printf(
-		"[Part Two] The answer is: %7d\t(elfs #%d #%d #%d)\n",
-		topThreeElfsMostCalories, topThreeElfsWithTheMostCalories[0] + 1,
-		topThreeElfsWithTheMostCalories[1] + 1,
-		topThreeElfsWithTheMostCalories[2] + 1,
+	topElfsWithTheMostCalories, topElfsMostCalories := topK.getTop()
+	fmt.Fprintf(
+		answers,
+		"[Part Two] The answer is: %7d\t(elfs %s)\n",
+		topElfsMostCalories,
+		formatElfs(topElfsWithTheMostCalories),
 	)
+
+	switch *statsFormat {
+	case "table":
+		err = writeStatsTable(os.Stdout, stats)
+	case "json":
+		err = writeStatsJSON(os.Stdout, stats)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed writing the statistics: %s\n", err)
+		os.Exit(1)
+	}
 }
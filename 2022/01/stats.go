@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// ElfStats are the statistics of the snacks an elf is carrying.
+type ElfStats struct {
+	Elf    int     `json:"elf"`
+	Count  int     `json:"count"`
+	Total  int     `json:"total"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	Max    int     `json:"max"`
+}
+
+// getElfStats calculates the statistics of the snacks of an elf.
+func getElfStats(elf int, snacks []int) ElfStats {
+	stats := ElfStats{Elf: elf, Count: len(snacks)}
+	if len(snacks) == 0 {
+		return stats
+	}
+
+	sorted := append([]int{}, snacks...)
+	sort.Ints(sorted)
+
+	for _, snack := range sorted {
+		stats.Total += snack
+	}
+
+	middle := len(sorted) / 2
+	stats.Mean = float64(stats.Total) / float64(len(sorted))
+	stats.Median = float64(sorted[middle])
+	if len(sorted)%2 == 0 {
+		stats.Median = float64(sorted[middle-1]+sorted[middle]) / 2
+	}
+	stats.Max = sorted[len(sorted)-1]
+
+	return stats
+}
+
+// writeStatsTable writes the statistics as a table with aligned columns.
+func writeStatsTable(w io.Writer, stats []ElfStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "elf\tcount\ttotal\tmean\tmedian\tmax\t")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%.2f\t%.1f\t%d\t\n", s.Elf, s.Count, s.Total, s.Mean, s.Median, s.Max)
+	}
+
+	return tw.Flush()
+}
+
+// writeStatsJSON writes the statistics as a JSON array.
+func writeStatsJSON(w io.Writer, stats []ElfStats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(stats)
+}
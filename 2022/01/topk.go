@@ -0,0 +1,73 @@
+package main
+
+import "container/heap"
+
+// Ranked is an elf and the total number of calories it is carrying.
+type Ranked struct {
+	elf      int
+	calories int
+}
+
+// before checks if the elf ranks before the other one: more calories first,
+// and the first elf on ties.
+func (r Ranked) before(other Ranked) bool {
+	if r.calories != other.calories {
+		return r.calories > other.calories
+	}
+
+	return r.elf < other.elf
+}
+
+// rankedHeap is a heap with the lowest ranked elf at the top.
+type rankedHeap []Ranked
+
+func (h rankedHeap) Len() int            { return len(h) }
+func (h rankedHeap) Less(i, j int) bool  { return h[j].before(h[i]) }
+func (h rankedHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *rankedHeap) Push(x interface{}) { *h = append(*h, x.(Ranked)) }
+func (h *rankedHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return last
+}
+
+// TopK keeps the K elfs carrying the most calories seen so far, in
+// O(log K) time per elf.
+type TopK struct {
+	k    int
+	elfs rankedHeap
+}
+
+// newTopK returns an empty selector of the top K elfs.
+func newTopK(k int) *TopK {
+	return &TopK{k: k}
+}
+
+// add considers an elf for the top K.
+func (t *TopK) add(elf int, calories int) {
+	r := Ranked{elf, calories}
+
+	if len(t.elfs) < t.k {
+		heap.Push(&t.elfs, r)
+	} else if t.k > 0 && r.before(t.elfs[0]) {
+		t.elfs[0] = r
+		heap.Fix(&t.elfs, 0)
+	}
+}
+
+// getTop returns the top elfs, from the most calories to the least, and the
+// total number of calories they are carrying.
+func (t *TopK) getTop() ([]Ranked, int) {
+	h := append(rankedHeap{}, t.elfs...)
+	top := make([]Ranked, len(h))
+	total := 0
+
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(&h).(Ranked)
+		total += top[i].calories
+	}
+
+	return top, total
+}
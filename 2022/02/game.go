@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// ROCK_PAPER_SCISSORS is the game in the puzzle, one shape per line with its
+// score and the shapes it beats.
+var ROCK_PAPER_SCISSORS = []string{
+	"Rock 1 Scissors",
+	"Paper 2 Rock",
+	"Scissors 3 Paper",
+}
+
+// ROCK_PAPER_SCISSORS_LIZARD_SPOCK is the five-way variant of the game.
+var ROCK_PAPER_SCISSORS_LIZARD_SPOCK = []string{
+	"Rock 1 Scissors Lizard",
+	"Paper 2 Rock Spock",
+	"Scissors 3 Paper Lizard",
+	"Lizard 4 Paper Spock",
+	"Spock 5 Rock Scissors",
+}
+
+// BUILTIN_GAMES are the games that can be picked by name.
+var BUILTIN_GAMES = map[string][]string{
+	"rps":   ROCK_PAPER_SCISSORS,
+	"rpsls": ROCK_PAPER_SCISSORS_LIZARD_SPOCK,
+}
+
+// Shape is a shape of the game, numbered in the order it was defined.
+type Shape int
+
+// Game is a definition of the game: its shapes, the score for choosing each
+// one, and which shapes beat which.
+type Game struct {
+	names  []string
+	scores []int
+	beats  [][]bool
+}
+
+// newGame returns the game defined by lines such as "Rock 1 Scissors", each
+// being a shape, its score and the shapes it beats. Empty lines and lines
+// starting with # are ignored.
+func newGame(definition []string) (*Game, error) {
+	g := &Game{}
+	shapes := make(map[string]Shape)
+	var beaten [][]string
+
+	for i, line := range definition {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if len(fields) < 2 {
+			return nil, helpers.NewParseError(i+1, 0, line, "expected a shape and its score")
+		}
+
+		if _, ok := shapes[fields[0]]; ok {
+			return nil, helpers.NewParseError(i+1, 0, line, "shape %s is defined twice", fields[0])
+		}
+
+		score, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, helpers.NewParseError(i+1, 0, line, "invalid score %q", fields[1])
+		}
+
+		shapes[fields[0]] = Shape(len(g.names))
+		g.names = append(g.names, fields[0])
+		g.scores = append(g.scores, score)
+		beaten = append(beaten, fields[2:])
+	}
+
+	if len(g.names) < 2 {
+		return nil, fmt.Errorf("a game needs at least two shapes")
+	}
+
+	// build the beats graph
+	g.beats = make([][]bool, len(g.names))
+	for s := range g.beats {
+		g.beats[s] = make([]bool, len(g.names))
+	}
+
+	for s, names := range beaten {
+		for _, name := range names {
+			other, ok := shapes[name]
+			if !ok {
+				return nil, fmt.Errorf("shape %s beats unknown shape %s", g.names[s], name)
+			}
+
+			if other == Shape(s) {
+				return nil, fmt.Errorf("shape %s cannot beat itself", name)
+			}
+
+			g.beats[s][other] = true
+		}
+	}
+
+	for s := range g.beats {
+		for other := range g.beats[s] {
+			if g.beats[s][other] && g.beats[other][s] {
+				return nil, fmt.Errorf("shapes %s and %s beat each other", g.names[s], g.names[other])
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// getGame returns the builtin game with the given name, or the one defined in
+// the file with that name.
+func getGame(name string) (*Game, error) {
+	if definition, ok := BUILTIN_GAMES[name]; ok {
+		return newGame(definition)
+	}
+
+	if _, err := os.Stat(name); err != nil {
+		return nil, fmt.Errorf("unknown game %q, expected rps, rpsls or a file", name)
+	}
+
+	return newGame(helpers.ReadFile(name))
+}
+
+// getNumOfShapes returns the number of shapes in the game.
+func (g *Game) getNumOfShapes() int {
+	return len(g.names)
+}
+
+// getName returns the name of a shape.
+func (g *Game) getName(s Shape) string {
+	return g.names[s]
+}
+
+// calculateScore calculates the score for a chosen shape.
+func (g *Game) calculateScore(response Shape) int {
+	return g.scores[response]
+}
+
+// calculateOutcome calculates the outcome of a round.
+func (g *Game) calculateOutcome(opponent Shape, response Shape) Outcome {
+	switch {
+	case g.beats[response][opponent]:
+		return Win
+	case g.beats[opponent][response]:
+		return Lose
+	}
+
+	return Draw
+}
+
+// calculateResponse calculates the response of a round. When several shapes
+// give the outcome, the one with the highest score is chosen.
+func (g *Game) calculateResponse(opponent Shape, outcome Outcome) (Shape, error) {
+	response := Shape(-1)
+
+	for s := Shape(0); int(s) < g.getNumOfShapes(); s++ {
+		if g.calculateOutcome(opponent, s) != outcome {
+			continue
+		}
+
+		if response < 0 || g.calculateScore(s) > g.calculateScore(response) {
+			response = s
+		}
+	}
+
+	if response < 0 {
+		return 0, fmt.Errorf("no shape can %s against %s", strings.ToLower(outcome.String()), g.getName(opponent))
+	}
+
+	return response, nil
+}
+
+// calculateRoundScore calculates the score for a round.
+func (g *Game) calculateRoundScore(opponent Shape, response Shape) int {
+	scoreForChosenShape := g.calculateScore(response)
+	scoreForRoundOutcome := g.calculateOutcome(opponent, response).Int()
+
+	return scoreForChosenShape + scoreForRoundOutcome
+}
+
+// calculateTotalScore calculates the total score for the game.
+func (g *Game) calculateTotalScore(s1 []Shape, s2 []Shape) int {
+	totalScore := 0
+
+	for i, shape := range s1 {
+		totalScore += g.calculateRoundScore(shape, s2[i])
+	}
+
+	return totalScore
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
@@ -9,48 +11,19 @@ import (
 
 const WHITESPACE = " "
 
-// Shape is a type of shape for the game (enum).
-type Shape int
+var gameName = flag.String("game", "rps", "builtin game (rps, rpsls) or a file defining one shape per line as \"shape score beaten-shapes...\"")
+var guide = flag.String("guide", "both", "read the second column of the guide as \"shapes\" (part one), \"outcomes\" (part two) or \"both\"")
+var optimal = flag.Bool("optimal", false, "report the optimal strategy against the opponent's shapes")
 
 // Outcome is a type of outcome for the game (enum).
 type Outcome int
 
-const (
-	Rock Shape = iota
-	Paper
-	Scissors
-)
-
 const (
 	Draw Outcome = iota
 	Win
 	Lose
 )
 
-func (s Shape) String() string {
-	switch s {
-	case Rock:
-		return "Rock"
-	case Paper:
-		return "Paper"
-	case Scissors:
-		return "Scissors"
-	}
-	return ""
-}
-
-func (s Shape) Int() int {
-	switch s {
-	case Rock:
-		return 1
-	case Paper:
-		return 2
-	case Scissors:
-		return 3
-	}
-	return 0
-}
-
 func (o Outcome) String() string {
 	switch o {
 	case Draw:
@@ -75,175 +48,103 @@ func (o Outcome) Int() int {
 	return 0
 }
 
-// calculateScore calculates the score for a chosen shape.
-func calculateScore(response Shape) int {
-	switch response {
-	case Rock:
-		return 1
-	case Paper:
-		return 2
-	case Scissors:
-		return 3
-	}
-	return 0
-}
-
-// calculateOutcome calculates the outcome of a round.
-func calculateOutcome(opponent Shape, response Shape) int {
-	if opponent == response {
-		return Draw.Int()
-	}
-
-	switch opponent {
-	case Rock:
-		if response == Paper {
-			return Win.Int()
-		}
-		return Lose.Int()
-	case Paper:
-		if response == Scissors {
-			return Win.Int()
+// convertOpponentToShape converts the opponent's code to a shape, the shapes
+// being coded from A in the order they are defined.
+func (g *Game) convertOpponentToShape(input string) (Shape, error) {
+	if len(input) == 1 {
+		if s := Shape(input[0] - 'A'); s >= 0 && int(s) < g.getNumOfShapes() {
+			return s, nil
 		}
-		return Lose.Int()
-	case Scissors:
-		if response == Rock {
-			return Win.Int()
-		}
-		return Lose.Int()
 	}
 
-	return 0
+	return 0, fmt.Errorf("invalid opponent shape %q", input)
 }
 
-// calculateResponse calculates the response of a round.
-func calculateResponse(opponent Shape, outcome Outcome) Shape {
-	if outcome == Draw {
-		return opponent
-	}
-
-	switch outcome {
-	case Win:
-		switch opponent {
-		case Rock:
-			return Paper
-		case Paper:
-			return Scissors
-		case Scissors:
-			return Rock
-		}
-	case Lose:
-		switch opponent {
-		case Rock:
-			return Scissors
-		case Paper:
-			return Rock
-		case Scissors:
-			return Paper
+// convertResponseToShape converts the response to a shape (part 1), the shapes
+// being coded so that the last one is Z.
+func (g *Game) convertResponseToShape(input string) (Shape, error) {
+	if len(input) == 1 {
+		if s := Shape(input[0]) - Shape('Z'-g.getNumOfShapes()+1); s >= 0 && int(s) < g.getNumOfShapes() {
+			return s, nil
 		}
 	}
 
-	return 0
-}
-
-// calculateRoundScore calculates the score for a round.
-func calculateRoundScore(opponent Shape, response Shape) int {
-	scoreForChosenShape := calculateScore(response)
-	scoreForRoundOutcome := calculateOutcome(opponent, response)
-
-	return scoreForChosenShape + scoreForRoundOutcome
-}
-
-// calculateTotalScore calculates the total score for the game.
-func calculateTotalScore(s1 []Shape, s2 []Shape) int {
-	totalScore := 0
-
-	for i, shape := range s1 {
-		totalScore += calculateRoundScore(shape, s2[i])
-	}
-
-	return totalScore
-}
-
-// convertOpponentToShape converts the opponent's response to a shape.
-func convertOpponentToShape(input string) Shape {
-	switch input {
-	case "A":
-		return Rock
-	case "B":
-		return Paper
-	case "C":
-		return Scissors
-	}
-	return 0
+	return 0, fmt.Errorf("invalid response shape %q", input)
 }
 
-// convertResponseToShape converts the response to a shape (part 1).
-func convertResponseToShape(input string) Shape {
+// convertResponseToOutcome converts the response to an outcome (part 2).
+func convertResponseToOutcome(input string) (Outcome, error) {
 	switch input {
 	case "X":
-		return Rock
+		return Lose, nil
 	case "Y":
-		return Paper
+		return Draw, nil
 	case "Z":
-		return Scissors
+		return Win, nil
 	}
-	return 0
+	return 0, fmt.Errorf("invalid outcome %q", input)
 }
 
-// convertResponseToShape converts the response to a shape (part 2).
-func convertResponseToOutcome(input string) Outcome {
-	switch input {
-	case "X":
-		return Lose
-	case "Y":
-		return Draw
-	case "Z":
-		return Win
-	}
-	return 0
-}
+// parseStrategyGuide splits every line of the guide by a white space into the
+// opponent's shape and the code in the second column.
+func (g *Game) parseStrategyGuide(input []string) ([]Shape, []string, error) {
+	var shapes []Shape
+	var codes []string
 
-// convertShapesAndOutcomesToResponses converts the shapes and outcomes to responses.
-func convertShapesAndOutcomesToResponses(opponent []Shape, outcome []Outcome) []Shape {
-	responses := make([]Shape, len(opponent))
+	for i, line := range input {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		strategy := strings.Split(strings.TrimSpace(line), WHITESPACE)
+		if len(strategy) != 2 {
+			return nil, nil, helpers.NewParseError(i+1, 0, line, "expected two columns")
+		}
+
+		shape, err := g.convertOpponentToShape(strategy[0])
+		if err != nil {
+			return nil, nil, helpers.AtLine(err, i+1, line)
+		}
 
-	for i, shape := range opponent {
-		responses[i] = calculateResponse(shape, outcome[i])
+		shapes = append(shapes, shape)
+		codes = append(codes, strategy[1])
 	}
 
-	return responses
+	return shapes, codes, nil
 }
 
-// convertInputToShapes converts the input to shapes (part 1).
-func convertInputToShapes(input []string) ([]Shape, []Shape) {
-	shapes := make([]Shape, len(input))
-	responses := make([]Shape, len(input))
-
-	for i, line := range input {
-		// split the line by a white space into two parts
-		strategy := strings.Split(line, WHITESPACE)
+// convertCodesToShapes reads the second column of the guide as the shapes to
+// respond with (part 1).
+func (g *Game) convertCodesToShapes(codes []string) ([]Shape, error) {
+	responses := make([]Shape, len(codes))
 
-		shapes[i] = convertOpponentToShape(string(strategy[0]))
-		responses[i] = convertResponseToShape(string(strategy[1]))
+	for i, code := range codes {
+		var err error
+		if responses[i], err = g.convertResponseToShape(code); err != nil {
+			return nil, fmt.Errorf("round %d: %w", i+1, err)
+		}
 	}
 
-	return shapes, responses
+	return responses, nil
 }
 
-// convertInputToShapes converts the input to shapes (part 2).
-func convertInputToShapesAndOutcomes(input []string) ([]Shape, []Outcome) {
-	shapes := make([]Shape, len(input))
-	outcomes := make([]Outcome, len(input))
+// convertCodesToResponses reads the second column of the guide as the
+// outcomes of the rounds, and finds the shapes to respond with (part 2).
+func (g *Game) convertCodesToResponses(opponent []Shape, codes []string) ([]Shape, error) {
+	responses := make([]Shape, len(codes))
 
-	for i, line := range input {
-		// split the line by a white space into two parts
-		strategy := strings.Split(line, WHITESPACE)
+	for i, code := range codes {
+		outcome, err := convertResponseToOutcome(code)
+		if err == nil {
+			responses[i], err = g.calculateResponse(opponent[i], outcome)
+		}
 
-		shapes[i] = convertOpponentToShape(string(strategy[0]))
-		outcomes[i] = convertResponseToOutcome(string(strategy[1]))
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i+1, err)
+		}
 	}
 
-	return shapes, outcomes
+	return responses, nil
 }
 
 // main is the entry point for the application.
@@ -253,22 +154,69 @@ func main() {
 	filename := args[0]
 	txtlines := helpers.ReadFile(filename)
 
+	if *guide != "both" && *guide != "shapes" && *guide != "outcomes" {
+		fmt.Fprintf(os.Stderr, "unknown guide %q, expected shapes, outcomes or both\n", *guide)
+		os.Exit(1)
+	}
+
+	game, err := getGame(*gameName)
+	if err == nil && game.getNumOfShapes() > 26 {
+		err = fmt.Errorf("a game can have at most 26 shapes, got %d", game.getNumOfShapes())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid game: %s\n", err)
+		os.Exit(1)
+	}
+
 	// process the file
-	shapesPartOne, responsesPartOne := convertInputToShapes(txtlines)
+	shapes, codes, err := game.parseStrategyGuide(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the strategy guide: %s\n", err)
+		os.Exit(1)
+	}
 
 	// part 1
-	totalScorePartOne := calculateTotalScore(shapesPartOne, responsesPartOne)
-	fmt.Printf(
-		"[Part One] The answer is: %d\n",
-		totalScorePartOne,
-	)
+	if *guide != "outcomes" {
+		responses, err := game.convertCodesToShapes(codes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed reading the guide as shapes: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf(
+			"[Part One] The answer is: %d\n",
+			game.calculateTotalScore(shapes, responses),
+		)
+	}
 
 	// part 2
-	shapes, outcomes := convertInputToShapesAndOutcomes(txtlines)
-	responses := convertShapesAndOutcomesToResponses(shapes, outcomes)
-	totalScorePartTwo := calculateTotalScore(shapes, responses)
-	fmt.Printf(
-		"[Part Two] The answer is: %d\n",
-		totalScorePartTwo,
-	)
+	if *guide != "shapes" {
+		responses, err := game.convertCodesToResponses(shapes, codes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed reading the guide as outcomes: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf(
+			"[Part Two] The answer is: %d\n",
+			game.calculateTotalScore(shapes, responses),
+		)
+	}
+
+	if *optimal {
+		strategy, score := game.findOptimalStrategy(shapes)
+
+		for s := Shape(0); int(s) < game.getNumOfShapes(); s++ {
+			if response, ok := strategy[s]; ok {
+				fmt.Printf(
+					"Against %s, play %s (%s)\n",
+					game.getName(s),
+					game.getName(response),
+					game.calculateOutcome(s, response),
+				)
+			}
+		}
+
+		fmt.Printf("[Optimal] The best score is: %d\n", score)
+	}
 }
@@ -0,0 +1,34 @@
+package main
+
+// Strategy is the response to play against each shape of the opponent.
+type Strategy map[Shape]Shape
+
+// findOptimalStrategy finds the responses with the highest total score
+// against the opponent's shapes. The rounds are independent, so the best
+// response to a shape is the one with the highest score for a single round,
+// the first defined one winning ties.
+func (g *Game) findOptimalStrategy(opponent []Shape) (Strategy, int) {
+	strategy := make(Strategy)
+
+	for _, shape := range opponent {
+		if _, ok := strategy[shape]; ok {
+			continue
+		}
+
+		best := Shape(0)
+		for s := Shape(1); int(s) < g.getNumOfShapes(); s++ {
+			if g.calculateRoundScore(shape, s) > g.calculateRoundScore(shape, best) {
+				best = s
+			}
+		}
+
+		strategy[shape] = best
+	}
+
+	responses := make([]Shape, len(opponent))
+	for i, shape := range opponent {
+		responses[i] = strategy[shape]
+	}
+
+	return strategy, g.calculateTotalScore(opponent, responses)
+}
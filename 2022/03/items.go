@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// ItemSet is a set of item types as a bitmask, the bit of an item being its
+// priority minus one, so the 52 item types fit in a single word.
+type ItemSet uint64
+
+// Priorities maps the item types to their priorities and back.
+type Priorities struct {
+	runeToInt map[rune]int
+	intToRune map[int]rune
+}
+
+// newPriorities returns the priorities of the item types.
+func newPriorities() *Priorities {
+	p := &Priorities{
+		runeToInt: createRuneToIntoMap(),
+		intToRune: make(map[int]rune),
+	}
+
+	for r, priority := range p.runeToInt {
+		p.intToRune[priority] = r
+	}
+
+	return p
+}
+
+// newItemSet returns the set of item types in the items.
+func (p *Priorities) newItemSet(items string) (ItemSet, error) {
+	var set ItemSet
+
+	for _, item := range items {
+		priority, ok := p.runeToInt[item]
+		if !ok {
+			return 0, fmt.Errorf("invalid item %q", item)
+		}
+
+		set |= 1 << (priority - 1)
+	}
+
+	return set, nil
+}
+
+// getItems returns the item types in the set, sorted by priority.
+func (p *Priorities) getItems(set ItemSet) []rune {
+	items := []rune{}
+
+	for ; set != 0; set &= set - 1 {
+		items = append(items, p.intToRune[bits.TrailingZeros64(uint64(set))+1])
+	}
+
+	return items
+}
+
+// getPriority returns the sum of the priorities of the item types in the set.
+func (set ItemSet) getPriority() int {
+	priority := 0
+
+	for ; set != 0; set &= set - 1 {
+		priority += bits.TrailingZeros64(uint64(set)) + 1
+	}
+
+	return priority
+}
+
+// formatItems returns the item types in the set with their priorities, like
+// "p (16), L (38)".
+func (p *Priorities) formatItems(set ItemSet) string {
+	items := []string{}
+
+	for _, item := range p.getItems(set) {
+		items = append(items, fmt.Sprintf("%c (%d)", item, p.runeToInt[item]))
+	}
+
+	if len(items) == 0 {
+		return "none"
+	}
+
+	return strings.Join(items, ", ")
+}
+
+// findSharedItems returns the item types found in every one of the sets.
+func findSharedItems(sets ...ItemSet) ItemSet {
+	shared := ^ItemSet(0)
+
+	for _, set := range sets {
+		shared &= set
+	}
+
+	return shared
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"strings"
 	"unicode"
 
@@ -11,6 +13,10 @@ import (
 // ALPHABET is the english alphabet.
 const ALPHABET = "abcdefghijklmnopqrstuvwxyz"
 
+var numOfCompartments = flag.Int("compartments", 2, "number of compartments in each rucksack")
+var groupSize = flag.Int("group", 3, "number of rucksacks in each group")
+var report = flag.Bool("report", false, "list the shared items of every rucksack and group with their priorities")
+
 // createRuneToIntoMap creates a map of runes to integers.
 func createRuneToIntoMap() map[rune]int {
 	counterStart := 1
@@ -30,108 +36,106 @@ func createRuneToIntoMap() map[rune]int {
 	return runeToInt
 }
 
-// calculateItemPriority calculates the priority of an item.
-func calculateItemPriority(itemMap map[rune]int, item string) int {
-	priority := 0
-
-	for _, item := range item {
-		priority += itemMap[item]
-	}
-
-	return priority
+// Rucksack is the item types in each compartment of a rucksack.
+type Rucksack struct {
+	compartments []ItemSet
 }
 
-// calculateItemsPriority calculates the priority of a list of items.
-func calculateItemsPriority(itemMap map[rune]int, items []string) []int {
-	itemsPriority := []int{}
+// getItems returns the item types in any of the compartments.
+func (r Rucksack) getItems() ItemSet {
+	var items ItemSet
 
-	for _, item := range items {
-		itemsPriority = append(itemsPriority, calculateItemPriority(itemMap, item))
+	for _, compartment := range r.compartments {
+		items |= compartment
 	}
 
-	return itemsPriority
+	return items
 }
 
-// findCommongItemsInArrays finds the common items in two arrays.
-func findCommongItemsInArrays(arr1 []string, arr2 []string) []string {
-	// create a map to store the items found
-	commonItems := make(map[string]bool)
+// parseRucksacks splits the items of each rucksack into compartments of the
+// same size.
+func parseRucksacks(lines []string, priorities *Priorities, numOfCompartments int) ([]Rucksack, error) {
+	var rucksacks []Rucksack
 
-	// find the common items
-	for _, item := range arr1 {
-		if !commonItems[item] && helpers.StrArrayContains(arr2, item) {
-			commonItems[item] = true
+	for i, line := range lines {
+		items := strings.TrimSpace(line)
+		if items == "" {
+			continue
 		}
-	}
 
-	// convert the map to a slice
-	commonItemsSlice := []string{}
-	for item := range commonItems {
-		commonItemsSlice = append(commonItemsSlice, item)
-	}
+		if len(items)%numOfCompartments != 0 {
+			return nil, helpers.NewParseError(i+1, 0, line, "%d items cannot be split into %d compartments", len(items), numOfCompartments)
+		}
 
-	return commonItemsSlice
-}
+		size := len(items) / numOfCompartments
+		rucksack := Rucksack{}
+
+		for start := 0; start < len(items); start += size {
+			compartment, err := priorities.newItemSet(items[start : start+size])
+			if err != nil {
+				return nil, helpers.AtLine(err, i+1, line)
+			}
 
-// findCommonItemsInRucksack finds the common items in a rucksack.
-func findCommonItemsInRucksack(items string) []string {
-	// split the string in half
-	half := len(items) / 2
-	firstHalf := strings.Split(items[:half], "")
-	secondHalf := strings.Split(items[half:], "")
+			rucksack.compartments = append(rucksack.compartments, compartment)
+		}
 
-	// convert the map to a slice
-	commonItemsSlice := findCommongItemsInArrays(firstHalf, secondHalf)
+		rucksacks = append(rucksacks, rucksack)
+	}
 
-	return commonItemsSlice
+	return rucksacks, nil
 }
 
-// findCommonItemInRucksacks finds the common item in a list of rucksacks.
-func findCommonItemInRucksacks(items []string) []string {
-	commonItemInRucksacks := []string{}
+// findSharedItemsInRucksacks finds the items shared by the compartments of
+// each rucksack.
+func findSharedItemsInRucksacks(rucksacks []Rucksack) []ItemSet {
+	sharedItems := []ItemSet{}
 
-	for _, item := range items {
-		commonItems := findCommonItemsInRucksack(item)
-		commonItemInRucksacks = append(commonItemInRucksacks, commonItems[0])
+	for _, rucksack := range rucksacks {
+		sharedItems = append(sharedItems, findSharedItems(rucksack.compartments...))
 	}
 
-	return commonItemInRucksacks
+	return sharedItems
 }
 
-// findCommonItemInRucksacksPerGroup finds the common item in a list of rucksacks.
-func findCommonItemInRucksacksPerGroup(items []string) []string {
-	groupSize := 3
-	commonItemInRucksacks := []string{}
+// findSharedItemsPerGroup finds the items shared by the rucksacks of each
+// group.
+func findSharedItemsPerGroup(rucksacks []Rucksack, groupSize int) ([]ItemSet, error) {
+	if len(rucksacks)%groupSize != 0 {
+		return nil, fmt.Errorf("%d rucksacks cannot be split into groups of %d", len(rucksacks), groupSize)
+	}
 
-	for i := 0; i < len(items); i += groupSize {
-		groupItems := items[i : i+groupSize]
+	sharedItems := []ItemSet{}
 
-		commonItems := [][]string{}
-		for j := 0; j < groupSize - 1; j += 1 {
-			firstGroupItems := strings.Split(groupItems[j], "")
-			secondGroupItems := strings.Split(groupItems[j+1], "")
-			commonItems = append(commonItems, findCommongItemsInArrays(firstGroupItems, secondGroupItems))
+	for i := 0; i < len(rucksacks); i += groupSize {
+		groupItems := make([]ItemSet, groupSize)
+		for j, rucksack := range rucksacks[i : i+groupSize] {
+			groupItems[j] = rucksack.getItems()
 		}
 
-		commonItemsInRucksacks := findCommongItemsInArrays(commonItems[0], commonItems[1])
-
-		commonItemInRucksacks = append(commonItemInRucksacks, commonItemsInRucksacks[0])
+		sharedItems = append(sharedItems, findSharedItems(groupItems...))
 	}
 
-	return commonItemInRucksacks
+	return sharedItems, nil
 }
 
-// calculateTotalPriorities calculates the total priority of a list of priorities.
-func calculateTotalPriorities(priorities []int) int {
+// calculateTotalPriorities calculates the total priority of the shared items.
+func calculateTotalPriorities(sharedItems []ItemSet) int {
 	totalPriority := 0
 
-	for _, priority := range priorities {
-		totalPriority += priority
+	for _, items := range sharedItems {
+		totalPriority += items.getPriority()
 	}
 
 	return totalPriority
 }
 
+// printReport prints every shared item with its priority.
+func printReport(priorities *Priorities, label string, sharedItems []ItemSet) {
+	for i, items := range sharedItems {
+		fmt.Printf("%s %d: %s\n", label, i+1, priorities.formatItems(items))
+	}
+}
+
 // main is the entry point for the application.
 func main() {
 	// read the file
@@ -139,24 +143,41 @@ func main() {
 	filename := args[0]
 	txtlines := helpers.ReadFile(filename)
 
+	if *numOfCompartments < 1 || *groupSize < 1 {
+		fmt.Fprintln(os.Stderr, "the compartments and group size must be at least 1")
+		os.Exit(1)
+	}
+
 	// create the map of runes to integers
-	runeToInt := createRuneToIntoMap()
+	priorities := newPriorities()
+
+	rucksacks, err := parseRucksacks(txtlines, priorities, *numOfCompartments)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the rucksacks: %s\n", err)
+		os.Exit(1)
+	}
 
 	// part 1
-	commonItems := findCommonItemInRucksacks(txtlines)
-	commonItemsPriority := calculateItemsPriority(runeToInt, commonItems)
-	totalPriority := calculateTotalPriorities(commonItemsPriority)
+	sharedItems := findSharedItemsInRucksacks(rucksacks)
+	if *report {
+		printReport(priorities, "Rucksack", sharedItems)
+	}
 	fmt.Printf(
 		"[Part One] The answer is: %d\n",
-		totalPriority,
+		calculateTotalPriorities(sharedItems),
 	)
 
 	// part 2
-	commonItemsPerGroup := findCommonItemInRucksacksPerGroup(txtlines)
-	commonItemsPerGroupPriority := calculateItemsPriority(runeToInt, commonItemsPerGroup)
-	totalPerGroupPriority := calculateTotalPriorities(commonItemsPerGroupPriority)
+	sharedItemsPerGroup, err := findSharedItemsPerGroup(rucksacks, *groupSize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed grouping the rucksacks: %s\n", err)
+		os.Exit(1)
+	}
+	if *report {
+		printReport(priorities, "Group", sharedItemsPerGroup)
+	}
 	fmt.Printf(
 		"[Part Two] The answer is: %d\n",
-		totalPerGroupPriority,
+		calculateTotalPriorities(sharedItemsPerGroup),
 	)
 }
@@ -2,8 +2,7 @@ package main
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
+	"os"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
@@ -80,27 +79,63 @@ func findFullyOverlappingSections(cleaningSections [][]Section) [][]Section {
 	return overlappingSections
 }
 
-// getCleaningSectionsFromInput returns the cleaning sections from the input.
-func getCleaningSectionsFromInput(input []string) [][]Section {
-	var cleaningSections [][]Section
+// parseSection parses a section such as "2-4" at the cursor.
+func parseSection(cursor *helpers.LineCursor) (Section, error) {
+	col := cursor.Col()
+	start, err := cursor.Int()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cursor.Expect(SECTION_SEPARATOR); err != nil {
+		return nil, err
+	}
 
-	for _, line := range input {
-		sections := strings.Split(line, ELF_SEPARATOR)
+	end, err := cursor.Int()
+	if err != nil {
+		return nil, err
+	}
+
+	if start > end {
+		return nil, cursor.ErrorfAt(col, "the section %d-%d ends before it starts", start, end)
+	}
 
+	return Section{start, end}, nil
+}
+
+// getCleaningSectionsFromInput returns the cleaning sections from the input,
+// a pair of sections per line.
+func getCleaningSectionsFromInput(input []string) ([][]Section, error) {
+	var cleaningSections [][]Section
+
+	cursor := helpers.NewLineCursor(input)
+	for cursor.NextNonBlank() {
 		var cleaningSection []Section
 
-		for _, section := range sections {
-			sectionNumbers := strings.Split(section, SECTION_SEPARATOR)
-			start, _ := strconv.Atoi(sectionNumbers[0])
-			end, _ := strconv.Atoi(sectionNumbers[1])
+		for i := 0; i < 2; i++ {
+			if i > 0 {
+				if err := cursor.Expect(ELF_SEPARATOR); err != nil {
+					return nil, err
+				}
+			}
+
+			cursor.SkipSpaces()
+			section, err := parseSection(cursor)
+			if err != nil {
+				return nil, err
+			}
 
-			cleaningSection = append(cleaningSection, Section{start, end})
+			cleaningSection = append(cleaningSection, section)
+		}
+
+		if err := cursor.ExpectEnd(); err != nil {
+			return nil, err
 		}
 
 		cleaningSections = append(cleaningSections, cleaningSection)
 	}
 
-	return cleaningSections
+	return cleaningSections, nil
 }
 
 // main is the entry point for the application.
@@ -111,7 +146,11 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	cleaningSections := getCleaningSectionsFromInput(txtlines)
+	cleaningSections, err := getCleaningSectionsFromInput(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the sections: %s\n", err)
+		os.Exit(1)
+	}
 
 	// part 1
 	fullyOverlappingSections := findFullyOverlappingSections(cleaningSections)
@@ -2,14 +2,14 @@ package main
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
+	"os"
 	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
 const VERBOSE = false
+const PROCEDURE_PATTERN = "move {move:int} from {from:int} to {to:int}"
 
 type Procedure struct {
 	Move int
@@ -118,39 +118,72 @@ func arrangeStacks(stacks Stacks, procedures []Procedure) Stacks {
 	return arrangedStacks
 }
 
-// parseProcedure parses a procedure into a Procedure struct.
-func parseProcedure(procedure string) Procedure {
+// parseProcedure parses a procedure such as "move 1 from 2 to 1" into a
+// Procedure struct.
+func parseProcedure(line string) (Procedure, error) {
 	var parsedProcedure Procedure
 
-	re := regexp.MustCompile(`move (\d+) from (\d+) to (\d+)`)
-	matches := re.FindStringSubmatch(procedure)
-
-	parsedProcedure.Move, _ = strconv.Atoi(matches[1])
-	parsedProcedure.From, _ = strconv.Atoi(matches[2])
-	parsedProcedure.To, _ = strconv.Atoi(matches[3])
+	if err := helpers.MustCompilePattern(PROCEDURE_PATTERN).Unmarshal(line, &parsedProcedure); err != nil {
+		return parsedProcedure, err
+	}
 
 	parsedProcedure.From = parsedProcedure.From - 1
 	parsedProcedure.To = parsedProcedure.To - 1
 
-	return parsedProcedure
+	return parsedProcedure, nil
 }
 
-// parseProcedures parses the procedures into a slice of Procedure structs.
-func parseProcedures(procedures []string) []Procedure {
+// parseProcedures parses the procedures into a slice of Procedure structs,
+// checking that every stack exists and has enough crates to move.
+func parseProcedures(procedures []string, firstLine int, stacks Stacks) ([]Procedure, error) {
 	var parsedProcedures []Procedure
 
-	for _, procedure := range procedures {
-		parsedProcedure := parseProcedure(procedure)
+	heights := make([]int, len(stacks))
+	for i, stack := range stacks {
+		heights[i] = len(stack)
+	}
+
+	cursor := helpers.NewLineCursor(procedures)
+	for cursor.NextNonBlank() {
+		parsedProcedure, err := parseProcedure(cursor.Text())
+		if err == nil {
+			err = checkProcedure(parsedProcedure, heights)
+		}
+
+		if err != nil {
+			return nil, helpers.AtLine(err, firstLine+cursor.Line()-1, cursor.Text())
+		}
+
+		heights[parsedProcedure.From] -= parsedProcedure.Move
+		heights[parsedProcedure.To] += parsedProcedure.Move
+
 		parsedProcedures = append(parsedProcedures, parsedProcedure)
 	}
 
-	return parsedProcedures
+	return parsedProcedures, nil
+}
+
+// checkProcedure checks that a procedure can be carried out on stacks of the
+// given heights.
+func checkProcedure(procedure Procedure, heights []int) error {
+	for _, stack := range []int{procedure.From, procedure.To} {
+		if stack < 0 || stack >= len(heights) {
+			return fmt.Errorf("there is no stack %d", stack+1)
+		}
+	}
+
+	if procedure.Move < 0 || procedure.Move > heights[procedure.From] {
+		return fmt.Errorf("cannot move %d crates from stack %d, which has %d", procedure.Move, procedure.From+1, heights[procedure.From])
+	}
+
+	return nil
 }
 
 // parseStacks parses the stacks into a map of stacks.
-func parseStacks(stacks []string) Stacks {
-	// create map of stacks using numbers as keys
-	stacksMap := make(Stacks, len(stacks))
+func parseStacks(stacks []string) (Stacks, error) {
+	if len(stacks) == 0 {
+		return nil, fmt.Errorf("the input has no stacks")
+	}
 
 	// copy the stacks
 	var parsedStacks []string
@@ -161,38 +194,58 @@ func parseStacks(stacks []string) Stacks {
 		parsedStacks[i], parsedStacks[j] = parsedStacks[j], parsedStacks[i]
 	}
 
+	// the stacks are numbered from 1 under the crates
+	labels := helpers.SplitFields(parsedStacks[0], " ")
+	for i, label := range labels {
+		stackNumber, err := label.Int()
+		if err == nil && stackNumber != i+1 {
+			err = label.Errorf("expected stack %d, got %d", i+1, stackNumber)
+		}
+
+		if err != nil {
+			return nil, helpers.AtLine(err, len(stacks), stacks[len(stacks)-1])
+		}
+	}
+
+	// create map of stacks using numbers as keys
+	stacksMap := make(Stacks, len(labels))
+
 	// parse the stacks matrix
-	for i, value := range parsedStacks[0] {
-		stackNumber, err := strconv.Atoi(string(value))
-		stackIndex := stackNumber - 1
+	for stackIndex, label := range labels {
+		i := label.Col - 1
 
-		if err == nil {
-			for _, stackString := range parsedStacks[1:] {
-				if len(stackString) <= i {
-					continue
-				}
+		for j, stackString := range parsedStacks[1:] {
+			if len(stackString) <= i {
+				continue
+			}
 
-				crate := strings.TrimSpace(string(stackString[i]))
+			crate := strings.TrimSpace(string(stackString[i]))
 
-				if crate != "" {
-					stacksMap[stackIndex] = append(stacksMap[stackIndex], string(stackString[i]))
+			if crate != "" {
+				if i == 0 || stackString[i-1] != '[' || i+1 >= len(stackString) || stackString[i+1] != ']' {
+					return nil, helpers.NewParseError(len(stacks)-j-1, i+1, stackString, "expected a crate such as [%s]", crate)
 				}
+
+				stacksMap[stackIndex] = append(stacksMap[stackIndex], string(stackString[i]))
 			}
 		}
 	}
 
-	return stacksMap
+	return stacksMap, nil
 }
 
-// getStacksAndProcedures returns the stacks and procedures from the input.
-func getStacksAndProcedures(input []string) ([]string, []string) {
+// getStacksAndProcedures returns the stacks and procedures from the input,
+// and the number of the line the procedures start at.
+func getStacksAndProcedures(input []string) ([]string, []string, int) {
 	var stacks []string
 	var procedures []string
+	firstLine := len(input) + 1
 
 	stackIsDone := false
-	for _, line := range input {
-		if line == "" {
+	for i, line := range input {
+		if line == "" && !stackIsDone {
 			stackIsDone = true
+			firstLine = i + 2
 			continue
 		}
 
@@ -203,7 +256,7 @@ func getStacksAndProcedures(input []string) ([]string, []string) {
 		}
 	}
 
-	return stacks, procedures
+	return stacks, procedures, firstLine
 }
 
 // main is the entry point for the application.
@@ -214,9 +267,18 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	stacks, procedures := getStacksAndProcedures(txtlines)
-	parsedStacks := parseStacks(stacks)
-	parsedProcedures := parseProcedures(procedures)
+	stacks, procedures, firstLine := getStacksAndProcedures(txtlines)
+	parsedStacks, err := parseStacks(stacks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing the stacks: %s\n", err)
+		os.Exit(1)
+	}
+
+	parsedProcedures, err := parseProcedures(procedures, firstLine, parsedStacks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing the procedures: %s\n", err)
+		os.Exit(1)
+	}
 
 	// part 1
 	arrangedStacks := arrangeStacks(parsedStacks, parsedProcedures)
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
@@ -9,52 +11,46 @@ import (
 const startOfPacketMarker = 4
 const startOfMessageMarker = 14
 
-// pop removes the nth element from a slice.
-func pop(i int, xs []rune) (rune, []rune) {
-  y := xs[i]
-  ys := append(xs[:i], xs[i+1:]...)
-  return y, ys
-}
+var markerLength = flag.Int("length", 0, "also find the markers of this length")
+var all = flag.Bool("all", false, "list every marker of every message")
 
-// getCharactersBeforePacketMarker returns the number of characters before the packet marker.
-func getCharactersBeforePacketMarker(message string, startMarker int) int {
-	var charactersSeen []rune
-	var numCharactersSeen int
-
-	for count, character := range message {
-		if helpers.RuneArrayContains(charactersSeen, character) {
-			// remove the elements up to it from the list
-			var removedCharacter rune
-			var newList []rune
-			for removedCharacter != character {
-				removedCharacter, newList = pop(0, charactersSeen)
-				charactersSeen = newList
-			}
-		}
+// getCharactersBeforeMarkers returns the number of characters before the
+// first marker of each length in the first message, or -1 when the message
+// has no marker of that length.
+func getCharactersBeforeMarkers(filename string, lengths []int, fn func(m Marker)) ([]int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-		charactersSeen = append(charactersSeen, character)
+	charactersBeforeMarker := make([]int, len(lengths))
+	for i := range charactersBeforeMarker {
+		charactersBeforeMarker[i] = -1
+	}
 
-		if len(charactersSeen) == startMarker {
-			numCharactersSeen = count
-			break
+	err = scanMarkers(file, lengths, func(m Marker) {
+		for i, length := range lengths {
+			if m.message == 1 && m.length == length && charactersBeforeMarker[i] < 0 {
+				charactersBeforeMarker[i] = m.position
+			}
 		}
-	}
 
-	return numCharactersSeen + 1
-}
+		fn(m)
+	})
 
-// getCharactersBeforePacketMarkers returns the number of characters before the packet marker for each line.
-func getCharactersBeforePacketMarkers(messages []string, startMarker int) []int {
-	var charactersBeforePacketMarker []int
+	return charactersBeforeMarker, err
+}
 
-	for _, message := range messages {
-		charactersBeforePacketMarker = append(
-			charactersBeforePacketMarker,
-			getCharactersBeforePacketMarker(message, startMarker),
-		)
+// printAnswer prints the number of characters before a marker, or that there
+// is no marker.
+func printAnswer(label string, charactersBeforeMarker int) {
+	if charactersBeforeMarker < 0 {
+		fmt.Printf("[%s] No marker was found\n", label)
+		return
 	}
 
-	return charactersBeforePacketMarker
+	fmt.Printf("[%s] The answer is: %d\n", label, charactersBeforeMarker)
 }
 
 // main is the entry point for the application.
@@ -62,25 +58,33 @@ func main() {
 	// read the file
 	args := helpers.ReadArguments()
 	filename := args[0]
-	txtlines := helpers.ReadFile(filename)
+
+	lengths := []int{startOfPacketMarker, startOfMessageMarker}
+	if *markerLength < 0 {
+		fmt.Fprintf(os.Stderr, "invalid marker length %d\n", *markerLength)
+		os.Exit(1)
+	}
+	if *markerLength > 0 {
+		lengths = append(lengths, *markerLength)
+	}
+
+	charactersBeforeMarkers, err := getCharactersBeforeMarkers(filename, lengths, func(m Marker) {
+		if *all {
+			fmt.Printf("Message %d: marker of %d at %d (%s)\n", m.message, m.length, m.position, m.text)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the messages: %s\n", err)
+		os.Exit(1)
+	}
 
 	// part 1
-	charactersBeforePacketMarker := getCharactersBeforePacketMarkers(
-		txtlines,
-		startOfPacketMarker,
-	)
-	fmt.Printf(
-		"[Part One] The answer is: %d\n",
-		charactersBeforePacketMarker[0],
-	)
+	printAnswer("Part One", charactersBeforeMarkers[0])
 
 	// part 2
-	charactersBeforeMessageMarker := getCharactersBeforePacketMarkers(
-		txtlines,
-		startOfMessageMarker,
-	)
-	fmt.Printf(
-		"[Part Two] The answer is: %d\n",
-		charactersBeforeMessageMarker[0],
-	)
+	printAnswer("Part Two", charactersBeforeMarkers[1])
+
+	if *markerLength > 0 {
+		printAnswer(fmt.Sprintf("Length %d", *markerLength), charactersBeforeMarkers[2])
+	}
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
+
+// ASCII_SIZE is the number of characters counted in an array, the others
+// being counted in a map.
+const ASCII_SIZE = utf8.RuneSelf
+
+// Marker is a sequence of different characters in a message.
+type Marker struct {
+	message  int
+	length   int
+	position int
+	text     string
+}
+
+// MarkerWindow is a window over the last characters of a message that counts
+// how many times each one appears, so checking whether they are all different
+// takes O(1) time per character.
+type MarkerWindow struct {
+	length      int
+	characters  []rune
+	seen        int
+	asciiCounts [ASCII_SIZE]int
+	otherCounts map[rune]int
+	repeated    int
+}
+
+// newMarkerWindow returns an empty window for markers of the given length.
+func newMarkerWindow(length int) *MarkerWindow {
+	return &MarkerWindow{
+		length:      length,
+		characters:  make([]rune, length),
+		otherCounts: make(map[rune]int),
+	}
+}
+
+// count adds the change to the count of a character and returns its new
+// count.
+func (w *MarkerWindow) count(character rune, change int) int {
+	if character < ASCII_SIZE {
+		w.asciiCounts[character] += change
+		return w.asciiCounts[character]
+	}
+
+	w.otherCounts[character] += change
+	count := w.otherCounts[character]
+	if count == 0 {
+		delete(w.otherCounts, character)
+	}
+
+	return count
+}
+
+// push adds a character to the window, dropping the oldest one when it's
+// full, and checks if the window is now a marker.
+func (w *MarkerWindow) push(character rune) bool {
+	slot := w.seen % w.length
+
+	if w.seen >= w.length {
+		if w.count(w.characters[slot], -1) == 1 {
+			w.repeated--
+		}
+	}
+
+	if w.count(character, 1) == 2 {
+		w.repeated++
+	}
+
+	w.characters[slot] = character
+	w.seen++
+
+	return w.seen >= w.length && w.repeated == 0
+}
+
+// getText returns the characters in the window, from the oldest to the newest.
+func (w *MarkerWindow) getText() string {
+	text := make([]rune, 0, w.length)
+
+	for i := w.seen - w.length; i < w.seen; i++ {
+		text = append(text, w.characters[i%w.length])
+	}
+
+	return string(text)
+}
+
+// scanMarkers reads the messages from the reader, one per line, calling the
+// function with every marker of each of the lengths. The position of a marker
+// is the number of characters read from its message up to its end.
+func scanMarkers(r io.Reader, lengths []int, fn func(m Marker)) error {
+	reader := bufio.NewReader(r)
+	message := 1
+	var windows []*MarkerWindow
+
+	reset := func() {
+		windows = make([]*MarkerWindow, len(lengths))
+		for i, length := range lengths {
+			windows[i] = newMarkerWindow(length)
+		}
+	}
+	reset()
+
+	for {
+		character, _, err := reader.ReadRune()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch character {
+		case '\r':
+			continue
+		case '\n':
+			message++
+			reset()
+			continue
+		}
+
+		for _, w := range windows {
+			if w.push(character) {
+				fn(Marker{message, w.length, w.seen, w.getText()})
+			}
+		}
+	}
+}
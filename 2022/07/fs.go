@@ -0,0 +1,219 @@
+package main
+
+import (
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileSystem exposes a reconstructed folder tree as a read-only fs.FS. The
+// transcript only records file sizes, so files read as zero-filled data of
+// their recorded size.
+type FileSystem struct {
+	root *Folder
+}
+
+// newFileSystem returns a file system rooted at the given folder.
+func newFileSystem(root *Folder) *FileSystem {
+	return &FileSystem{root}
+}
+
+// entryInfo describes a file or folder and serves both as fs.FileInfo and as
+// fs.DirEntry.
+type entryInfo struct {
+	name   string
+	size   int64
+	folder *Folder
+}
+
+func (e *entryInfo) Name() string               { return e.name }
+func (e *entryInfo) Size() int64                { return e.size }
+func (e *entryInfo) ModTime() time.Time         { return time.Time{} }
+func (e *entryInfo) IsDir() bool                { return e.folder != nil }
+func (e *entryInfo) Sys() interface{}           { return nil }
+func (e *entryInfo) Info() (fs.FileInfo, error) { return e, nil }
+func (e *entryInfo) Type() fs.FileMode          { return e.Mode().Type() }
+
+// Mode returns read-only permissions for files and folders.
+func (e *entryInfo) Mode() fs.FileMode {
+	if e.IsDir() {
+		return fs.ModeDir | 0555
+	}
+
+	return 0444
+}
+
+// newFolderInfo returns the description of a folder.
+func newFolderInfo(folder *Folder) *entryInfo {
+	name := folder.Name
+	if folder.Parent == nil {
+		name = "."
+	}
+
+	return &entryInfo{name, 0, folder}
+}
+
+// newFileInfo returns the description of a file.
+func newFileInfo(file File) *entryInfo {
+	return &entryInfo{file.Name, file.Size, nil}
+}
+
+// getEntries returns the contents of a folder sorted by name.
+func getEntries(folder *Folder) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, folder.countEntries())
+
+	for _, subfolder := range folder.Folders {
+		entries = append(entries, newFolderInfo(subfolder))
+	}
+
+	for _, file := range folder.Files {
+		entries = append(entries, newFileInfo(file))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries
+}
+
+// openFile is an open regular file.
+type openFile struct {
+	info   *entryInfo
+	offset int64
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Close() error               { return nil }
+
+// Read fills the buffer with zeros until the recorded size is reached.
+func (f *openFile) Read(b []byte) (int, error) {
+	remaining := f.info.size - f.offset
+	if remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(b)) > remaining {
+		b = b[:remaining]
+	}
+
+	for i := range b {
+		b[i] = 0
+	}
+
+	f.offset += int64(len(b))
+
+	return len(b), nil
+}
+
+// openFolder is an open folder.
+type openFolder struct {
+	info    *entryInfo
+	entries []fs.DirEntry
+}
+
+func (d *openFolder) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openFolder) Close() error               { return nil }
+
+// Read always fails since folders have no contents of their own.
+func (d *openFolder) Read(b []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir returns the next n entries of the folder, or all of them if n <= 0.
+func (d *openFolder) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		d.entries = getEntries(d.info.folder)
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = []fs.DirEntry{}
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+
+	return entries, nil
+}
+
+// lookup resolves a slash-separated path to a folder or a file.
+func (fsys *FileSystem) lookup(op string, name string) (*Folder, *File, error) {
+	if !fs.ValidPath(name) {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	folder := fsys.root
+	if name == "." {
+		return folder, nil, nil
+	}
+
+	parts := strings.Split(name, DIR_SEPARATOR)
+	for i, part := range parts {
+		if subfolder, ok := folder.Folders[part]; ok {
+			folder = subfolder
+			continue
+		}
+
+		if file := folder.getFile(part); file != nil && i == len(parts)-1 {
+			return nil, file, nil
+		}
+
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return folder, nil, nil
+}
+
+// Open opens the named file or folder.
+func (fsys *FileSystem) Open(name string) (fs.File, error) {
+	folder, file, err := fsys.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if file != nil {
+		return &openFile{newFileInfo(*file), 0}, nil
+	}
+
+	return &openFolder{newFolderInfo(folder), nil}, nil
+}
+
+// ReadDir reads the named folder and returns its entries sorted by name.
+func (fsys *FileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	folder, _, err := fsys.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if folder == nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return getEntries(folder), nil
+}
+
+// Stat returns the description of the named file or folder.
+func (fsys *FileSystem) Stat(name string) (fs.FileInfo, error) {
+	folder, file, err := fsys.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if file != nil {
+		return newFileInfo(*file), nil
+	}
+
+	return newFolderInfo(folder), nil
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -23,6 +26,9 @@ const FOLDER_SIZE_THRESHOLD = int64(100000)
 const TOTAL_SYSTEM_SPACE = int64(70000000)
 const TOTAL_REQUIRED_SPACE = int64(30000000)
 
+var printTree = flag.Bool("tree", false, "print the reconstructed file system like tree")
+var printDiskUsage = flag.Bool("du", false, "print the folder sizes like du -h")
+
 // File represents a file in the file system.
 type File struct {
     Name string
@@ -31,36 +37,90 @@ type File struct {
 
 // Folder represents a folder in the file system.
 type Folder struct {
-    Name    string             `json:"name"`
-    Files   []File             `json:"files"`
-    Folders map[string]*Folder `json:"folders"`
-	Parent  *Folder            `json:"-"`
+    Name     string             `json:"name"`
+    Files    []File             `json:"files"`
+    Folders  map[string]*Folder `json:"folders"`
+	Parent   *Folder            `json:"-"`
+	Listings int                `json:"-"`
 }
 
 // newFolder creates a new folder and returns a pointer to it.
 func newFolder(name string, parent *Folder) *Folder {
-    return &Folder{name, []File{}, make(map[string]*Folder), parent}
+    return &Folder{name, []File{}, make(map[string]*Folder), parent, 0}
+}
+
+// getFile returns the file with the given name, if it exists.
+func (f *Folder) getFile(name string) *File {
+	for i := range f.Files {
+		if f.Files[i].Name == name {
+			return &f.Files[i]
+		}
+	}
+
+	return nil
+}
+
+// countEntries returns the number of files and folders in the current folder.
+func (f *Folder) countEntries() int {
+	return len(f.Files) + len(f.Folders)
 }
 
 // addFile adds a file to the current folder.
-func (f *Folder) addFile(input string) {
+func (f *Folder) addFile(input string) error {
 	// split the file size and name
-	sizeName := strings.Split(input, FILE_SIZE_NAME_SEPARATOR)
-	fileSizeInt, _ := strconv.ParseInt(sizeName[0], 10, 64)
+	sizeName := strings.SplitN(input, FILE_SIZE_NAME_SEPARATOR, 2)
+	if len(sizeName) != 2 || sizeName[1] == "" {
+		return fmt.Errorf("malformed ls output %q", input)
+	}
+
+	fileSizeInt, err := strconv.ParseInt(sizeName[0], 10, 64)
+	if err != nil || fileSizeInt < 0 {
+		return fmt.Errorf("invalid file size in %q", input)
+	}
+
 	fileName := sizeName[1]
 
+	// a repeated listing must agree with what was seen before
+	if _, ok := f.Folders[fileName]; ok {
+		return fmt.Errorf("%q is listed both as a file and a folder in %s", fileName, f.getPath())
+	}
+
+	if file := f.getFile(fileName); file != nil {
+		if file.Size != fileSizeInt {
+			return fmt.Errorf(
+				"file %q in %s listed with size %d, previously %d",
+				fileName, f.getPath(), fileSizeInt, file.Size,
+			)
+		}
+
+		return nil
+	}
+
 	// add the file to the folder
 	f.Files = append(f.Files, File{fileName, fileSizeInt})
+
+	return nil
 }
 
 // addFolder adds a subfolder to the current folder.
-func (f *Folder) addFolder(input string) {
+func (f *Folder) addFolder(input string) error {
 	// split the folder marker and name
-	markerName := strings.Split(input, FOLDER_MARKER_NAME_SEPARATOR)
-	folderName := markerName[1]
+	folderName := strings.TrimPrefix(input, FOLDER_MARKER)
+	if folderName == "" || strings.Contains(folderName, DIR_SEPARATOR) {
+		return fmt.Errorf("invalid folder name in %q", input)
+	}
+
+	// a repeated listing must agree with what was seen before
+	if f.getFile(folderName) != nil {
+		return fmt.Errorf("%q is listed both as a file and a folder in %s", folderName, f.getPath())
+	}
+
+	// keep the contents of folders that are already known
+	if _, ok := f.Folders[folderName]; !ok {
+		f.Folders[folderName] = newFolder(folderName, f)
+	}
 
-	// add the folder to the folder
-	f.Folders[folderName] = newFolder(folderName, f)
+	return nil
 }
 
 // getParent returns the parent folder of the current folder.
@@ -77,6 +137,20 @@ func (f *Folder) getRoot() *Folder {
 	return f.Parent.getRoot()
 }
 
+// getPath returns the absolute path of the current folder.
+func (f *Folder) getPath() string {
+	if f.Parent == nil {
+		return ROOT_MARKER
+	}
+
+	parentPath := f.Parent.getPath()
+	if parentPath == ROOT_MARKER {
+		return parentPath + f.Name
+	}
+
+	return parentPath + DIR_SEPARATOR + f.Name
+}
+
 // getSize returns the size of the current folder.
 func (f *Folder) getSize() int64 {
 	size := int64(0)
@@ -93,33 +167,71 @@ func (f *Folder) getSize() int64 {
 }
 
 // cd changes the current folder to the specified folder.
-func (f *Folder) cd(args string, output []string) *Folder {
+func (f *Folder) cd(args string) (*Folder, error) {
 	switch args {
 	case ROOT_MARKER:
-		return f.getRoot()
+		return f.getRoot(), nil
 	case PARENT_MARKER:
-		return f.getParent()
+		if f.Parent == nil {
+			return nil, fmt.Errorf("cd: %s has no parent folder", f.getPath())
+		}
+
+		return f.getParent(), nil
+	case "":
+		return nil, fmt.Errorf("cd: missing folder name")
 	default:
-		return f.Folders[args]
+		folder, ok := f.Folders[args]
+		if !ok {
+			return nil, fmt.Errorf("cd: no folder %q in %s", args, f.getPath())
+		}
+
+		return folder, nil
 	}
-	return nil
 }
 
-// ls lists the files and folders in the current folder.
-func (f *Folder) ls(args string, output []string) {
+// ls lists the files and folders in the current folder. Listing a folder more
+// than once is allowed as long as the output is the same every time.
+func (f *Folder) ls(args string, output []string) error {
+	if args != "" {
+		return fmt.Errorf("ls: unexpected arguments %q", args)
+	}
+
+	entriesBefore := f.countEntries()
+	entriesListed := 0
+
 	for _, line := range output {
 		if isCommand(line) {
 			break
-		} else if isFolder(line) {
-			f.addFolder(line)
+		} else if line == "" {
+			continue
+		}
+
+		var err error
+		if isFolder(line) {
+			err = f.addFolder(line)
 		} else {
-			f.addFile(line)
+			err = f.addFile(line)
 		}
+
+		if err != nil {
+			return fmt.Errorf("ls: %w", err)
+		}
+
+		entriesListed++
 	}
+
+	if f.Listings > 0 && (f.countEntries() != entriesBefore || entriesListed != entriesBefore) {
+		return fmt.Errorf("ls: listing of %s differs from an earlier listing", f.getPath())
+	}
+
+	f.Listings++
+
+	return nil
 }
 
-// execCommand executes a command in the current folder.
-func (f *Folder) execCommand(input string, output []string) *Folder {
+// execCommand executes a command in the current folder and returns the
+// folder that is current afterwards.
+func (f *Folder) execCommand(input string, output []string) (*Folder, error) {
 	// remove the command marker
 	command := strings.TrimPrefix(input, COMMAND_MARKER)
 
@@ -135,11 +247,41 @@ func (f *Folder) execCommand(input string, output []string) *Folder {
 	// execute the command
 	switch commandName {
 	case "cd":
-		return f.cd(commandArgs, output)
+		return f.cd(commandArgs)
 	case "ls":
-		f.ls(commandArgs, output)
+		return f, f.ls(commandArgs, output)
 	}
-	return nil
+
+	return nil, fmt.Errorf("unknown command %q", commandName)
+}
+
+// findDuplicateListings returns the paths of the folders listed more than
+// once.
+func findDuplicateListings(folder *Folder) []string {
+	duplicates := []string{}
+
+	if folder.Listings > 1 {
+		duplicates = append(duplicates, folder.getPath())
+	}
+
+	for _, name := range getSortedFolderNames(folder) {
+		duplicates = append(duplicates, findDuplicateListings(folder.Folders[name])...)
+	}
+
+	return duplicates
+}
+
+// getSortedFolderNames returns the names of the subfolders in sorted order.
+func getSortedFolderNames(folder *Folder) []string {
+	names := make([]string, 0, len(folder.Folders))
+
+	for name := range folder.Folders {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
 }
 
 // String returns a string representation of the current folder as JSON.
@@ -176,21 +318,31 @@ func isCommand(input string) bool {
 }
 
 // getFileSystem returns the file system represented by the input.
-func getFileSystem(txtlines []string) *Folder {
+func getFileSystem(txtlines []string) (*Folder, error) {
 	root := newFolder(ROOT_NAME, nil)
 
 	currentFolder := root
+	expectOutput := false
 	for i, line := range txtlines {
-		if isCommand(line) {
-			newFolder := currentFolder.execCommand(line, txtlines[i+1:])
-
-			if newFolder != nil {
-				currentFolder = newFolder
+		if !isCommand(line) {
+			// output lines are consumed by the ls command that precedes them
+			if line != "" && !expectOutput {
+				return nil, helpers.NewParseError(i+1, 0, line, "output without a preceding ls")
 			}
+
+			continue
+		}
+
+		newFolder, err := currentFolder.execCommand(line, txtlines[i+1:])
+		if err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
 		}
+
+		currentFolder = newFolder
+		expectOutput = strings.HasPrefix(line, COMMAND_MARKER+"ls")
 	}
 
-	return root
+	return root, nil
 }
 
 // getTotalSizeFoldersToDelete returns the total size of the folders to delete.
@@ -241,7 +393,33 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	filesystem := getFileSystem(txtlines)
+	filesystem, err := getFileSystem(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "inconsistent transcript: %s\n", err)
+		os.Exit(1)
+	}
+
+	for _, duplicate := range findDuplicateListings(filesystem) {
+		fmt.Fprintf(os.Stderr, "warning: %s was listed more than once\n", duplicate)
+	}
+
+	// reports
+	fsys := newFileSystem(filesystem)
+	if *printTree {
+		tree, err := renderTree(fsys)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(tree)
+	}
+
+	if *printDiskUsage {
+		du, err := renderDiskUsage(fsys)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(du)
+	}
 
 	// part 1
 	folderDeleteCandidates := findFoldersToDelete(filesystem, int64(0), FOLDER_SIZE_THRESHOLD)
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+)
+
+const TREE_BRANCH = "├── "
+const TREE_LAST_BRANCH = "└── "
+const TREE_INDENT = "│   "
+const TREE_LAST_INDENT = "    "
+const DU_UNITS = "KMGTPE"
+
+// renderTree returns the contents of the file system in the style of tree(1).
+func renderTree(fsys fs.FS) (string, error) {
+	var sb strings.Builder
+	folders, files := 0, 0
+
+	sb.WriteString(ROOT_MARKER + "\n")
+
+	var walk func(name string, prefix string) error
+	walk = func(name string, prefix string) error {
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		for i, entry := range entries {
+			branch, indent := TREE_BRANCH, TREE_INDENT
+			if i == len(entries)-1 {
+				branch, indent = TREE_LAST_BRANCH, TREE_LAST_INDENT
+			}
+
+			if !entry.IsDir() {
+				files++
+				info, err := entry.Info()
+				if err != nil {
+					return err
+				}
+
+				sb.WriteString(fmt.Sprintf("%s%s%s (%d)\n", prefix, branch, entry.Name(), info.Size()))
+				continue
+			}
+
+			folders++
+			sb.WriteString(prefix + branch + entry.Name() + "\n")
+
+			if err := walk(path.Join(name, entry.Name()), prefix+indent); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(".", ""); err != nil {
+		return "", err
+	}
+
+	sb.WriteString(fmt.Sprintf("\n%d directories, %d files\n", folders, files))
+
+	return sb.String(), nil
+}
+
+// renderDiskUsage returns the size of every folder in the style of du -h,
+// listing each folder after everything it contains.
+func renderDiskUsage(fsys fs.FS) (string, error) {
+	var sb strings.Builder
+	sizes := map[string]int64{}
+	open := []string{}
+
+	// print the folders that are done, i.e. not ancestors of the given path
+	flush := func(name string) {
+		for len(open) > 0 {
+			last := open[len(open)-1]
+			if last == "." || strings.HasPrefix(name, last+DIR_SEPARATOR) {
+				break
+			}
+
+			sb.WriteString(fmt.Sprintf("%s\t%s\n", formatHumanSize(sizes[last]), toAbsolutePath(last)))
+			open = open[:len(open)-1]
+		}
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		flush(name)
+
+		if entry.IsDir() {
+			open = append(open, name)
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		for _, folder := range open {
+			sizes[folder] += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	flush("")
+
+	if len(open) > 0 {
+		sb.WriteString(fmt.Sprintf("%s\t%s\n", formatHumanSize(sizes["."]), ROOT_MARKER))
+	}
+
+	return sb.String(), nil
+}
+
+// toAbsolutePath converts an fs.FS path into a path rooted at "/".
+func toAbsolutePath(name string) string {
+	if name == "." {
+		return ROOT_MARKER
+	}
+
+	return ROOT_MARKER + name
+}
+
+// formatHumanSize formats a size in bytes the way du -h does, rounding up to
+// one decimal below 10 and to a whole number otherwise.
+func formatHumanSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d", size)
+	}
+
+	value := float64(size)
+	unit := 0
+	for value /= 1024; value >= 1024 && unit < len(DU_UNITS)-1; value /= 1024 {
+		unit++
+	}
+
+	if value < 10 {
+		value = math.Ceil(value*10) / 10
+		if value < 10 {
+			return fmt.Sprintf("%.1f%c", value, DU_UNITS[unit])
+		}
+	}
+
+	return fmt.Sprintf("%.0f%c", math.Ceil(value), DU_UNITS[unit])
+}
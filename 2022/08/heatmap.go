@@ -0,0 +1,93 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+)
+
+const HEATMAP_RAMP = " .:-=+*#%@"
+
+// heatLevel maps a scenic score onto [0, 1] on a logarithmic scale, since a
+// handful of trees usually have scores orders of magnitude above the rest.
+func heatLevel(score int, maxScore int) float64 {
+	if maxScore <= 0 || score <= 0 {
+		return 0
+	}
+
+	return math.Log1p(float64(score)) / math.Log1p(float64(maxScore))
+}
+
+// heatColor returns a black-red-yellow-white colour for a level in [0, 1].
+func heatColor(level float64) color.RGBA {
+	channel := func(offset float64) uint8 {
+		return uint8(math.Round(255 * math.Max(0, math.Min(1, 3*level-offset))))
+	}
+
+	return color.RGBA{channel(0), channel(1), channel(2), 255}
+}
+
+// renderHeatmap returns the scenic scores as ASCII art, one character per tree.
+func (f *Forest) renderHeatmap() string {
+	var sb strings.Builder
+	maxScore := f.getMaxScenicScore()
+	lastLevel := float64(len(HEATMAP_RAMP) - 1)
+
+	for _, row := range f.ScenicScores {
+		for _, score := range row {
+			sb.WriteByte(HEATMAP_RAMP[int(math.Round(heatLevel(score, maxScore)*lastLevel))])
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// getHeatmapImage returns the scenic scores as an image with each tree drawn
+// as a square of scale x scale pixels.
+func (f *Forest) getHeatmapImage(scale int) *image.RGBA {
+	if scale < 1 {
+		scale = 1
+	}
+
+	rows := len(f.ScenicScores)
+	columns := 0
+	if rows > 0 {
+		columns = len(f.ScenicScores[0])
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, columns*scale, rows*scale))
+	maxScore := f.getMaxScenicScore()
+
+	for y, row := range f.ScenicScores {
+		for x, score := range row {
+			c := heatColor(heatLevel(score, maxScore))
+
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetRGBA(x*scale+dx, y*scale+dy, c)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// saveHeatmapPNG writes the scenic score heatmap to a PNG file.
+func (f *Forest) saveHeatmapPNG(filename string, scale int) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, f.getHeatmapImage(scale)); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
+	"os"
 	"strconv"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
+var printHeatmap = flag.Bool("heatmap", false, "print an ASCII heatmap of the scenic scores")
+var heatmapPNG = flag.String("png", "", "write a PNG heatmap of the scenic scores to this file")
+var heatmapScale = flag.Int("scale", 4, "size in pixels of each tree in the PNG heatmap")
+
 // Matrix is a matrix of integers.
 type Matrix [][]int
 
@@ -29,131 +36,6 @@ func (m Matrix) getRowLength() int {
 	return len(m[0])
 }
 
-// isEdge returns true if the point is on the edge of the matrix.
-func (m Matrix) isEdge(x, y int) bool {
-	columnLength := m.getColumnLength()
-	rowLength := m.getRowLength()
-
-	if x == 0 || y == 0 || x == rowLength-1 || y == columnLength-1 {
-		return true
-	}
-
-	return false
-}
-
-// isVisible returns true if the point is visible from the outside.
-func (m Matrix) isVisible(x, y int) bool {
-	if m.isEdge(x, y) {
-		return true
-	}
-
-	height := m.get(x, y)
-	isVisible := true
-
-	// search the top
-	for i := y - 1; i >= 0; i-- {
-		if m.get(x, i) >= height {
-			isVisible = false
-			break
-		}
-	}
-
-	if isVisible {
-		return true
-	}
-
-	isVisible = true
-
-	// search the bottom
-	for i := y + 1; i < m.getColumnLength(); i++ {
-		if m.get(x, i) >= height {
-			isVisible = false
-			break
-		}
-	}
-
-	if isVisible {
-		return true
-	}
-
-	isVisible = true
-
-	// search the left
-	for i := x - 1; i >= 0; i-- {
-		if m.get(i, y) >= height {
-			isVisible = false
-			break
-		}
-	}
-
-	if isVisible {
-		return true
-	}
-
-	isVisible = true
-
-	// search the right
-	for i := x + 1; i < m.getRowLength(); i++ {
-		if m.get(i, y) >= height {
-			isVisible = false
-			break
-		}
-	}
-
-	return isVisible
-}
-
-func (m Matrix) getScenicScore(x, y int) int {
-	if m.isEdge(x, y) {
-		return 0
-	}
-
-	viewingDistanceTop := 0
-	viewingDistanceBottom := 0
-	viewingDistanceLeft := 0
-	viewingDistanceRight := 0
-
-	height := m.get(x, y)
-
-	// search the top
-	for i := y - 1; i >= 0; i-- {
-		viewingDistanceTop++
-
-		if m.get(x, i) >= height {
-			break
-		}
-	}
-
-	// search the bottom
-	for i := y + 1; i < m.getColumnLength(); i++ {
-		viewingDistanceBottom++
-
-		if m.get(x, i) >= height {
-			break
-		}
-	}
-
-	// search the left
-	for i := x - 1; i >= 0; i-- {
-		viewingDistanceLeft++
-
-		if m.get(i, y) >= height {
-			break
-		}
-	}
-
-	// search the right
-	for i := x + 1; i < m.getRowLength(); i++ {
-		viewingDistanceRight++
-
-		if m.get(i, y) >= height {
-			break
-		}
-	}
-
-	return viewingDistanceTop * viewingDistanceBottom * viewingDistanceLeft * viewingDistanceRight
-}
-
 // String returns a string representation of the matrix.
 func (m Matrix) String() string {
 	s := ""
@@ -168,47 +50,35 @@ func (m Matrix) String() string {
 	return s
 }
 
-// calculateScenicScores returns the scenic scores for all points in the matrix.
-func calculateScenicScores(matrix Matrix) []int {
-	scenicScores := make([]int, 0)
+// getMatrixFromFile returns a matrix from a slice of strings, which must all
+// be rows of digits of the same length.
+func getMatrixFromFile(txtlines []string) (Matrix, error) {
+	var matrix Matrix
 
-	for y := 0; y < matrix.getColumnLength(); y++ {
-		for x := 0; x < matrix.getRowLength(); x++ {
-			scenicScores = append(scenicScores, matrix.getScenicScore(x, y))
+	for i, line := range txtlines {
+		if line == "" {
+			continue
 		}
-	}
 
-	return scenicScores
-}
-
-// calculateNumVisibleFromOutside returns the number of visible points from the outside.
-func calculateNumVisibleFromOutside(matrix Matrix) int {
-	numVisibleFromOutside := 0
-
-	for y := 0; y < matrix.getColumnLength(); y++ {
-		for x := 0; x < matrix.getRowLength(); x++ {
-			if matrix.isVisible(x, y) {
-				numVisibleFromOutside++
-			}
+		if len(matrix) > 0 && len(line) != len(matrix[0]) {
+			return nil, helpers.NewParseError(i+1, 0, line, "expected %d trees, got %d", len(matrix[0]), len(line))
 		}
-	}
 
-	return numVisibleFromOutside
-}
-
-// getMatrixFromFile returns a matrix from a slice of strings.
-func getMatrixFromFile(txtlines []string) Matrix {
-	matrix := make(Matrix, len(txtlines))
-
-	for i, line := range txtlines {
-		matrix[i] = make([]int, len(line))
+		row := make([]int, len(line))
 
 		for j, char := range line {
-			matrix[i][j], _ = strconv.Atoi(string(char))
+			height, err := strconv.Atoi(string(char))
+			if err != nil {
+				return nil, helpers.NewParseError(i+1, j+1, line, "invalid tree height %q", char)
+			}
+
+			row[j] = height
 		}
+
+		matrix = append(matrix, row)
 	}
 
-	return matrix
+	return matrix, nil
 }
 
 // main is the entry point for the application.
@@ -219,18 +89,33 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	matrix := getMatrixFromFile(txtlines)
+	matrix, err := getMatrixFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the trees: %s\n", err)
+		os.Exit(1)
+	}
+
+	forest := newForest(matrix)
+
+	if *printHeatmap {
+		fmt.Println(forest.renderHeatmap())
+	}
+
+	if *heatmapPNG != "" {
+		if err := forest.saveHeatmapPNG(*heatmapPNG, *heatmapScale); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// part 1
-	numVisibleFromOutside := calculateNumVisibleFromOutside(matrix)
+	numVisibleFromOutside := forest.countVisible()
 	fmt.Printf(
 		"[Part One] The answer is: %d\n",
 		numVisibleFromOutside,
 	)
 
 	// part 2
-	scenicScores := calculateScenicScores(matrix)
-	_, maxScenicScore := helpers.MinMax(scenicScores)
+	maxScenicScore := forest.getMaxScenicScore()
 	fmt.Printf(
 		"[Part Two] The answer is: %d\n",
 		maxScenicScore,
@@ -0,0 +1,118 @@
+package main
+
+// Forest holds the visibility and the scenic score of every tree in a matrix.
+type Forest struct {
+	Visible      [][]bool
+	ScenicScores [][]int
+}
+
+// sweepLine walks a line of trees once while keeping a stack of the trees that
+// still block the view, in decreasing order of height. For each tree it
+// reports how far it can see back towards the start of the line and whether
+// every tree in that direction is shorter.
+func sweepLine(length int, height func(i int) int, visit func(i int, distance int, visible bool)) {
+	stack := make([]int, 0, length)
+
+	for i := 0; i < length; i++ {
+		h := height(i)
+
+		// shorter trees can never block anything behind this one
+		for len(stack) > 0 && height(stack[len(stack)-1]) < h {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			visit(i, i, true)
+		} else {
+			visit(i, i-stack[len(stack)-1], false)
+		}
+
+		stack = append(stack, i)
+	}
+}
+
+// newForest computes the visibility and scenic scores of the whole matrix with
+// one sweep per direction, in O(rows x columns).
+func newForest(m Matrix) *Forest {
+	rows := m.getColumnLength()
+	columns := m.getRowLength()
+
+	forest := &Forest{
+		Visible:      make([][]bool, rows),
+		ScenicScores: make([][]int, rows),
+	}
+
+	for y := 0; y < rows; y++ {
+		forest.Visible[y] = make([]bool, columns)
+		forest.ScenicScores[y] = make([]int, columns)
+
+		for x := 0; x < columns; x++ {
+			forest.ScenicScores[y][x] = 1
+		}
+	}
+
+	// record returns a visitor that maps line positions back to the matrix
+	record := func(point func(i int) (int, int)) func(i int, distance int, visible bool) {
+		return func(i int, distance int, visible bool) {
+			x, y := point(i)
+			forest.Visible[y][x] = forest.Visible[y][x] || visible
+			forest.ScenicScores[y][x] *= distance
+		}
+	}
+
+	for y := 0; y < rows; y++ {
+		y := y
+
+		// looking left
+		left := func(i int) (int, int) { return i, y }
+		sweepLine(columns, func(i int) int { return m.get(left(i)) }, record(left))
+
+		// looking right
+		right := func(i int) (int, int) { return columns - 1 - i, y }
+		sweepLine(columns, func(i int) int { return m.get(right(i)) }, record(right))
+	}
+
+	for x := 0; x < columns; x++ {
+		x := x
+
+		// looking up
+		up := func(i int) (int, int) { return x, i }
+		sweepLine(rows, func(i int) int { return m.get(up(i)) }, record(up))
+
+		// looking down
+		down := func(i int) (int, int) { return x, rows - 1 - i }
+		sweepLine(rows, func(i int) int { return m.get(down(i)) }, record(down))
+	}
+
+	return forest
+}
+
+// countVisible returns the number of trees visible from outside the forest.
+func (f *Forest) countVisible() int {
+	count := 0
+
+	for _, row := range f.Visible {
+		for _, visible := range row {
+			if visible {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// getMaxScenicScore returns the highest scenic score in the forest.
+func (f *Forest) getMaxScenicScore() int {
+	maxScore := 0
+
+	for _, row := range f.ScenicScores {
+		for _, score := range row {
+			if score > maxScore {
+				maxScore = score
+			}
+		}
+	}
+
+	return maxScore
+}
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"math"
+	"os"
 	"strconv"
 	"strings"
 
@@ -12,7 +13,10 @@ import (
 const NUM_OF_KNOTS_PART_1 = 2
 const NUM_OF_KNOTS_PART_2 = 10
 
-// Direction is a cardinal direction.
+var numOfKnots = flag.Int("knots", 0, "also simulate a rope with this many knots")
+var showFrames = flag.Bool("frames", false, "print the rope after every step of the simulations")
+
+// Direction is a cardinal or diagonal direction.
 type Direction int
 
 const (
@@ -20,6 +24,10 @@ const (
 	Down
 	Left
 	Right
+	UpLeft
+	UpRight
+	DownLeft
+	DownRight
 )
 
 // Move is a direction and the number of steps to take.
@@ -39,16 +47,84 @@ func (d Direction) String() string {
 		return "Left"
 	case Right:
 		return "Right"
+	case UpLeft:
+		return "UpLeft"
+	case UpRight:
+		return "UpRight"
+	case DownLeft:
+		return "DownLeft"
+	case DownRight:
+		return "DownRight"
 	}
 	return ""
 }
 
+// Letters returns the letters used for a Direction in the input.
+func (d Direction) Letters() string {
+	switch d {
+	case Up:
+		return "U"
+	case Down:
+		return "D"
+	case Left:
+		return "L"
+	case Right:
+		return "R"
+	case UpLeft:
+		return "UL"
+	case UpRight:
+		return "UR"
+	case DownLeft:
+		return "DL"
+	case DownRight:
+		return "DR"
+	}
+	return ""
+}
+
+// delta returns the change in X and Y of a single step in a Direction.
+func (d Direction) delta() (int, int) {
+	switch d {
+	case Up:
+		return 0, 1
+	case Down:
+		return 0, -1
+	case Left:
+		return -1, 0
+	case Right:
+		return 1, 0
+	case UpLeft:
+		return -1, 1
+	case UpRight:
+		return 1, 1
+	case DownLeft:
+		return -1, -1
+	case DownRight:
+		return 1, -1
+	}
+	return 0, 0
+}
+
 // Point is a point in a 2D plane.
 type Point struct {
 	X int
 	Y int
 }
 
+// Bounds is the smallest rectangle containing a set of points.
+type Bounds struct {
+	Min Point
+	Max Point
+}
+
+// extend grows the bounds to include the given point.
+func (b *Bounds) extend(p Point) {
+	b.Min.X = helpers.MinOf(b.Min.X, p.X)
+	b.Min.Y = helpers.MinOf(b.Min.Y, p.Y)
+	b.Max.X = helpers.MaxOf(b.Max.X, p.X)
+	b.Max.Y = helpers.MaxOf(b.Max.Y, p.Y)
+}
+
 // Rope is a rope with a head and a tail.
 type Rope struct {
 	Start   Point
@@ -56,6 +132,8 @@ type Rope struct {
 	Body    []*Point
 	Tail    Point
 	Visited map[Point]bool
+	Bounds  Bounds
+	OnStep  func(r *Rope, move Move)
 }
 
 // newRope creates a new rope with a given start point and number of knots.
@@ -70,15 +148,18 @@ func newRope(start Point, numOfKnots int) *Rope {
 			knots,
 			start,
 			make(map[Point]bool),
+			Bounds{start, start},
+			nil,
 		}
 	rope.Visited[start] = true
 	return rope
 }
 
 // moveBody moves the body of the rope.
-func (r *Rope) moveBody(move Move) {
+func (r *Rope) moveBody() {
 	for i := 0; i < len(r.Body) - 1; i++ {
-		moveTail(move, r.Body[i], r.Body[i+1])
+		moveTail(r.Body[i], r.Body[i+1])
+		r.Bounds.extend(*r.Body[i+1])
 	}
 	r.Tail = *r.Body[len(r.Body)-1]
 	r.Visited[r.Tail] = true
@@ -86,20 +167,19 @@ func (r *Rope) moveBody(move Move) {
 
 // moveHead moves the head of the rope.
 func (r *Rope) moveHead(move Move) {
+	dx, dy := move.Direction.delta()
+
 	for move.Steps > 0 {
-		switch move.Direction {
-		case Up:
-			r.Head.Y += 1
-		case Down:
-			r.Head.Y -= 1
-		case Left:
-			r.Head.X -= 1
-		case Right:
-			r.Head.X += 1
-		}
+		r.Head.X += dx
+		r.Head.Y += dy
 
 		r.Body[0] = &Point{r.Head.X, r.Head.Y}
-		r.moveBody(move)
+		r.Bounds.extend(r.Head)
+		r.moveBody()
+
+		if r.OnStep != nil {
+			r.OnStep(r, move)
+		}
 
 		move.Steps -= 1
 	}
@@ -112,24 +192,14 @@ func (r *Rope) move(moves []Move) {
 	}
 }
 
-// moveTail moves a piece of the tail of the rope.
-func moveTail(move Move, head, tail *Point) {
+// moveTail moves a piece of the tail of the rope one step towards the piece
+// in front of it, when they are no longer touching.
+func moveTail(head, tail *Point) {
 	distanceX, distanceY := distanceInt(head, tail)
-	absDistanceX := helpers.AbsInt(distanceX)
-	absDistanceY := helpers.AbsInt(distanceY)
-	distance := distanceStraight(head, tail)
-
-	if head.X == tail.X || head.Y == tail.Y {
-		if absDistanceX > 1 {
-			tail.X += distanceX / absDistanceX
-		}
 
-		if absDistanceY > 1 {
-			tail.Y += distanceY / absDistanceY
-		}
-	} else if distance > math.Sqrt(2) {
-		tail.X += distanceX / absDistanceX
-		tail.Y += distanceY / absDistanceY
+	if helpers.AbsInt(distanceX) > 1 || helpers.AbsInt(distanceY) > 1 {
+		tail.X += helpers.SignInt(distanceX)
+		tail.Y += helpers.SignInt(distanceY)
 	}
 }
 
@@ -141,41 +211,58 @@ func distanceInt(p1, p2 *Point) (int, int) {
 	return xDistanceFromP1ToP2, yDistanceFromP1ToP2
 }
 
-// distanceStraight returns the straight distance between two points.
-func distanceStraight(p1 *Point, p2 *Point) float64 {
-	squares := helpers.Square(p1.X-p2.X) + helpers.Square(p1.Y-p2.Y)
-
-	return math.Sqrt(float64(squares))
-}
-
 // strToDirection converts a string to a Direction.
-func strToDirection(s string) Direction {
+func strToDirection(s string) (Direction, error) {
 	switch s {
 	case "U":
-		return Up
+		return Up, nil
 	case "D":
-		return Down
+		return Down, nil
 	case "L":
-		return Left
+		return Left, nil
 	case "R":
-		return Right
+		return Right, nil
+	case "UL":
+		return UpLeft, nil
+	case "UR":
+		return UpRight, nil
+	case "DL":
+		return DownLeft, nil
+	case "DR":
+		return DownRight, nil
 	}
-	return 0
+	return 0, fmt.Errorf("unknown direction %q", s)
 }
 
 // getMovesFromFile returns a list of moves from a list of strings.
-func getMovesFromFile(lines []string) []Move {
+func getMovesFromFile(lines []string) ([]Move, error) {
 	moves := []Move{}
 
-	for _, line := range lines {
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+
 		// split the line by whitespace
-		directionAndSteps := strings.Split(line, " ")
-		direction := strToDirection(directionAndSteps[0])
-		steps, _ := strconv.Atoi(directionAndSteps[1])
+		directionAndSteps := strings.Fields(line)
+		if len(directionAndSteps) != 2 {
+			return nil, helpers.NewParseError(i+1, 0, line, "expected a direction and a number of steps")
+		}
+
+		direction, err := strToDirection(directionAndSteps[0])
+		if err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
+		}
+
+		steps, err := strconv.Atoi(directionAndSteps[1])
+		if err != nil || steps < 0 {
+			return nil, helpers.NewParseError(i+1, 0, line, "invalid number of steps %q", directionAndSteps[1])
+		}
+
 		moves = append(moves, Move{direction, steps})
 	}
 
-	return moves
+	return moves, nil
 }
 
 // main is the entry point for the application.
@@ -186,21 +273,33 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	moves := getMovesFromFile(txtlines)
+	moves, err := getMovesFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing moves: %s\n", err)
+		os.Exit(1)
+	}
 
 	// part 1
-	ropeA := newRope(Point{0, 0}, NUM_OF_KNOTS_PART_1)
-	ropeA.move(moves)
+	ropeA := simulate(moves, NUM_OF_KNOTS_PART_1, *showFrames)
 	fmt.Printf(
 		"[Part One] The answer is: %d\n",
 		len(ropeA.Visited),
 	)
 
 	// part 2
-	ropeB := newRope(Point{0, 0}, NUM_OF_KNOTS_PART_2)
-	ropeB.move(moves)
+	ropeB := simulate(moves, NUM_OF_KNOTS_PART_2, *showFrames)
 	fmt.Printf(
 		"[Part Two] The answer is: %d\n",
 		len(ropeB.Visited),
 	)
+
+	// custom number of knots
+	if *numOfKnots > 0 {
+		ropeC := simulate(moves, *numOfKnots, *showFrames)
+		fmt.Printf(
+			"[%d Knots] The answer is: %d\n",
+			*numOfKnots,
+			len(ropeC.Visited),
+		)
+	}
 }
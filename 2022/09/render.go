@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const EMPTY_MARKER = '.'
+const START_MARKER = 's'
+const HEAD_MARKER = 'H'
+const TAIL_MARKER = 'T'
+const VISITED_MARKER = '#'
+const OVERFLOW_MARKER = '*'
+
+// getKnotMarker returns the character used to draw a knot, which is its index
+// in the rope as in the puzzle's diagrams.
+func (r *Rope) getKnotMarker(i int) rune {
+	switch {
+	case i == 0:
+		return HEAD_MARKER
+	case i == len(r.Body)-1 && len(r.Body) == NUM_OF_KNOTS_PART_1:
+		return TAIL_MARKER
+	case i < 36:
+		return rune(strconv.FormatInt(int64(i), 36)[0])
+	}
+
+	return OVERFLOW_MARKER
+}
+
+// newCanvas returns an empty drawing area covering the bounds.
+func newCanvas(bounds Bounds) [][]rune {
+	canvas := make([][]rune, bounds.Max.Y-bounds.Min.Y+1)
+
+	for y := range canvas {
+		canvas[y] = []rune(strings.Repeat(string(EMPTY_MARKER), bounds.Max.X-bounds.Min.X+1))
+	}
+
+	return canvas
+}
+
+// drawOnCanvas sets a point of the canvas, ignoring points outside the bounds.
+func drawOnCanvas(canvas [][]rune, bounds Bounds, p Point, marker rune) {
+	// rows are printed top to bottom while Y grows upwards
+	row := bounds.Max.Y - p.Y
+	column := p.X - bounds.Min.X
+
+	if row >= 0 && row < len(canvas) && column >= 0 && column < len(canvas[row]) {
+		canvas[row][column] = marker
+	}
+}
+
+// canvasToString joins the rows of a canvas.
+func canvasToString(canvas [][]rune) string {
+	var sb strings.Builder
+
+	for _, row := range canvas {
+		sb.WriteString(string(row))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// render draws the knots of the rope, with knots closer to the head covering
+// the ones behind them.
+func (r *Rope) render(bounds Bounds) string {
+	canvas := newCanvas(bounds)
+	drawOnCanvas(canvas, bounds, r.Start, START_MARKER)
+
+	for i := len(r.Body) - 1; i >= 0; i-- {
+		drawOnCanvas(canvas, bounds, *r.Body[i], r.getKnotMarker(i))
+	}
+
+	return canvasToString(canvas)
+}
+
+// renderVisited draws the positions visited by the tail of the rope.
+func (r *Rope) renderVisited(bounds Bounds) string {
+	canvas := newCanvas(bounds)
+
+	for p := range r.Visited {
+		drawOnCanvas(canvas, bounds, p, VISITED_MARKER)
+	}
+
+	drawOnCanvas(canvas, bounds, r.Start, START_MARKER)
+
+	return canvasToString(canvas)
+}
+
+// simulate moves a rope with the given number of knots through all the moves
+// and, when asked to, prints every step the way the puzzle does.
+func simulate(moves []Move, numOfKnots int, frames bool) *Rope {
+	rope := newRope(Point{0, 0}, numOfKnots)
+
+	if !frames {
+		rope.move(moves)
+		return rope
+	}
+
+	// a dry run finds the area covered, so every frame has the same size
+	dryRun := newRope(Point{0, 0}, numOfKnots)
+	dryRun.move(moves)
+	bounds := dryRun.Bounds
+
+	printFrames(os.Stdout, rope, moves, bounds)
+
+	return rope
+}
+
+// printFrames moves the rope and writes a frame after every step.
+func printFrames(w io.Writer, rope *Rope, moves []Move, bounds Bounds) {
+	fmt.Fprintf(w, "== Initial State (%d knots) ==\n\n%s\n", len(rope.Body), rope.render(bounds))
+
+	rope.OnStep = func(r *Rope, move Move) {
+		fmt.Fprintf(w, "%s\n", r.render(bounds))
+	}
+
+	for _, move := range moves {
+		fmt.Fprintf(w, "== %s %d ==\n\n", move.Direction.Letters(), move.Steps)
+		rope.moveHead(move)
+	}
+
+	rope.OnStep = nil
+
+	fmt.Fprintf(w, "== Visited ==\n\n%s\n", rope.renderVisited(bounds))
+}
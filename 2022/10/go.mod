@@ -1,4 +1,4 @@
-module github.com/joaocarmo/advent-of-code/2021/10
+module github.com/joaocarmo/advent-of-code/2022/10
 
 go 1.19
 
@@ -1,132 +1,127 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"log"
+	"os"
 	"strconv"
 	"strings"
 
+	"github.com/joaocarmo/advent-of-code/2022/10/vm"
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
-// Represents the separator between the operation and the argument.
-const OPERATION_ARGUMENT_SEPARATOR = " "
 // Represents a lit pixel.
 const PIXEL_LIT = "#"
 // Represents a dark pixel.
 const PIXEL_DARK = "."
 // Represents the width of the register.
 const REGISTER_WIDTH = 3
+// Represents the name of the X register.
+const REGISTER_X = "X"
+// Represents the separator between a register and a value in a breakpoint.
+const BREAKPOINT_SEPARATOR = "="
 
-// Operation is an enum that represents the operation.
-type Operation int
+var printTrace = flag.Bool("trace", false, "print every cycle executed by the CPU")
+var breakAtCycle = flag.Int("break-cycle", 0, "stop before the given cycle and print the registers")
+var breakOnRegister = flag.String("break-register", "", "stop whenever a register holds a value, e.g. X=21")
+var printScreen = flag.Bool("screen", false, "print the CRT screen along with the letters read from it")
 
-const (
-	NOOP Operation = iota
-	ADDX
-)
+// newInstructionSet returns the instructions understood by the CPU.
+func newInstructionSet() *vm.InstructionSet {
+	set := vm.NewInstructionSet(REGISTER_X)
 
-// String returns the string representation of the operation.
-func (o Operation) String() string {
-	switch o {
-	case NOOP:
-		return "noop"
-	case ADDX:
-		return "addx"
+	definitions := []vm.Definition{
+		// `noop` takes one cycle to complete. It has no other effect.
+		{Name: "noop", Cycles: 1},
+		// `addx V` takes two cycles to complete. After two cycles, the X register is increased by the value V.
+		{
+			Name:   "addx",
+			Cycles: 2,
+			Args:   []vm.ArgKind{vm.Immediate},
+			Effect: func(r vm.Registers, args []vm.Operand) {
+				r[REGISTER_X] += args[0].Get(r)
+			},
+		},
 	}
-	return ""
-}
-
-// Instruction is a struct that represents an instruction.
-type Instruction struct {
-	operation Operation
-	argument  int
-}
-
-// String returns the string representation of the instruction.
-func (i Instruction) String() string {
-	return fmt.Sprintf("%s %d\n", i.operation, i.argument)
-}
 
-// CPU is a struct that represents a CPU.
-type CPU struct {
-	cycle    int
-	register int
-	history  map[int]int
-}
-
-// getHistoryAt returns the history of the CPU at a given cycle.
-func (c *CPU) getHistoryAt(cycles []int) [][]int {
-	var history [][]int
-
-	for _, cycle := range cycles {
-		history = append(history, []int{cycle, c.history[cycle - 1]})
+	for _, definition := range definitions {
+		if err := set.Define(definition); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	return history
+	return set
 }
 
-// increaseCycle increases the cycle of the CPU by one.
-func (c *CPU) increaseCycle() {
-	c.history[c.cycle] = c.register
-	c.cycle++
-}
+// newCPU returns a new CPU loaded with the program.
+func newCPU(set *vm.InstructionSet, program []vm.Instruction) *vm.Machine {
+	cpu := vm.NewMachine(set, program)
+	cpu.Registers[REGISTER_X] = 1
 
-// updateRegister updates the register of the CPU.
-func (c *CPU) updateRegister(argument int) {
-	c.register += argument
+	return cpu
 }
 
-// execute executes an instruction.
-func (c *CPU) execute(instruction Instruction) {
-	c.increaseCycle()
-
-	switch instruction.operation {
-	case NOOP:
-		// `noop` takes one cycle to complete. It has no other effect.
-	case ADDX:
-		// `addx`` V takes two cycles to complete. After two cycles, the X register is increased by the value V.
-		c.increaseCycle()
-		c.updateRegister(instruction.argument)
+// parseRegisterBreakpoint parses a breakpoint such as "X=21".
+func parseRegisterBreakpoint(input string) (vm.Breakpoint, error) {
+	registerValue := strings.Split(input, BREAKPOINT_SEPARATOR)
+	if len(registerValue) != 2 {
+		return vm.Breakpoint{}, fmt.Errorf("invalid register breakpoint %q", input)
 	}
-}
 
-// executeAll executes all instructions.
-func (c *CPU) executeAll(instructions []Instruction) {
-	for _, instruction := range instructions {
-		c.execute(instruction)
+	value, err := strconv.Atoi(registerValue[1])
+	if err != nil {
+		return vm.Breakpoint{}, fmt.Errorf("invalid register breakpoint %q", input)
 	}
+
+	return vm.OnRegister(registerValue[0], value), nil
 }
 
-// String returns the string representation of the CPU.
-func (c CPU) String() string {
-	return fmt.Sprintf("cycle: %d, register: %d\n", c.cycle, c.register)
+// SignalRecorder records the X register during the cycles of interest.
+type SignalRecorder struct {
+	cycles  []int
+	history [][]int
 }
 
-// newCPU returns a new CPU.
-func newCPU() CPU {
-	return CPU{
-		cycle:    0,
-		register: 1,
-		history:  make(map[int]int),
+// observe records the X register if the cycle is one of interest.
+func (s *SignalRecorder) observe(event vm.Event) {
+	if helpers.IntArrayContains(s.cycles, event.Cycle) {
+		s.history = append(s.history, []int{event.Cycle, event.Registers[REGISTER_X]})
 	}
 }
 
+// newSignalRecorder returns a new SignalRecorder.
+func newSignalRecorder(cycles []int) *SignalRecorder {
+	return &SignalRecorder{cycles, [][]int{}}
+}
+
 // CRT is a struct that represents a CRT.
 type CRT struct {
 	Columns int
 	Rows    int
+	pixels  []bool
+}
+
+// observe draws the pixel of the cycle, lit if the sprite covers it.
+func (c *CRT) observe(event vm.Event) {
+	position := event.Cycle - 1
+	if position >= len(c.pixels) {
+		return
+	}
+
+	column := position % c.Columns
+	c.pixels[position] = helpers.AbsInt(event.Registers[REGISTER_X] - column) < REGISTER_WIDTH - 1
 }
 
 // drawSprite draws a sprite.
-func (c *CRT) drawSprite(cpu CPU) string {
+func (c *CRT) drawSprite() string {
 	var sprite string
 
 	for i := 0; i < c.Rows; i++ {
 		for j := 0; j < c.Columns; j++ {
-			cycle := i * c.Columns + j
-			register := cpu.history[cycle]
-
-			if helpers.AbsInt(register - j) < REGISTER_WIDTH - 1 {
+			if c.pixels[i * c.Columns + j] {
 				sprite += PIXEL_LIT
 			} else {
 				sprite += PIXEL_DARK
@@ -138,11 +133,23 @@ func (c *CRT) drawSprite(cpu CPU) string {
 	return sprite
 }
 
+// readLetters recognizes the block letters shown on the screen.
+func (c *CRT) readLetters() (string, error) {
+	rows := make([][]bool, c.Rows)
+
+	for i := range rows {
+		rows[i] = c.pixels[i * c.Columns : (i + 1) * c.Columns]
+	}
+
+	return helpers.RecognizePixels(rows)
+}
+
 // newCRT returns a new CRT.
-func newCRT(columns int, rows int) CRT {
-	return CRT{
+func newCRT(columns int, rows int) *CRT {
+	return &CRT{
 		Columns: columns,
 		Rows: rows,
+		pixels: make([]bool, columns * rows),
 	}
 }
 
@@ -169,38 +176,21 @@ func calcSumSignalStrengths(input [][]int) int {
 	return sum
 }
 
-// instructionFromString returns an instruction from a string.
-func instructionFromString(input string) Instruction {
-	var operation Operation
-	var argument int
-	operationArgument := strings.Split(input, OPERATION_ARGUMENT_SEPARATOR)
-
-	switch operationArgument[0] {
-	case "noop":
-		operation = NOOP
-	case "addx":
-		operation = ADDX
-	}
-
-	if len(operationArgument) > 1 {
-		argument, _ = strconv.Atoi(operationArgument[1])
-	}
-
-	return Instruction{
-		operation: operation,
-		argument:  argument,
-	}
-}
+// runCPU runs the CPU to completion, reporting the breakpoints hit on the way.
+func runCPU(cpu *vm.Machine) {
+	for {
+		err := cpu.Run()
+		if err == nil {
+			return
+		}
 
-// getInstructionsFromFile returns a slice of instructions from a slice of strings.
-func getInstructionsFromFile(input []string) []Instruction {
-	var instructions []Instruction
+		var breakpoint *vm.BreakpointError
+		if !errors.As(err, &breakpoint) {
+			log.Fatal(err)
+		}
 
-	for _, line := range input {
-		instructions = append(instructions, instructionFromString(line))
+		fmt.Printf("%s, %s = %d\n", breakpoint, REGISTER_X, breakpoint.Registers[REGISTER_X])
 	}
-
-	return instructions
 }
 
 // main is the entry point for the application.
@@ -211,23 +201,66 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	instructions := getInstructionsFromFile(txtlines)
+	set := newInstructionSet()
+	instructions, err := set.ParseProgram(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing program: %s\n", err)
+		os.Exit(1)
+	}
+
+	cpu := newCPU(set, instructions)
+	recorder := newSignalRecorder([]int{20, 60, 100, 140, 180, 220})
+	crt := newCRT(40, 6)
+	tracer := &vm.Tracer{}
+	cpu.Subscribe(recorder.observe)
+	cpu.Subscribe(crt.observe)
+	cpu.Subscribe(tracer.Record)
+
+	if *breakAtCycle > 0 {
+		cpu.AddBreakpoint(vm.AtCycle(*breakAtCycle))
+	}
+
+	if *breakOnRegister != "" {
+		breakpoint, err := parseRegisterBreakpoint(*breakOnRegister)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cpu.AddBreakpoint(breakpoint)
+	}
+
+	runCPU(cpu)
+
+	if *printTrace {
+		if err := tracer.Dump(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// part 1
-	cpu := newCPU()
-	cpu.executeAll(instructions)
-	history := cpu.getHistoryAt([]int{20, 60, 100, 140, 180, 220})
-	sumSignalStrength := calcSumSignalStrengths(history)
+	sumSignalStrength := calcSumSignalStrengths(recorder.history)
 	fmt.Printf(
 		"[Part One] The answer is: %d\n",
 		sumSignalStrength,
 	)
 
 	// part 2
-	crt := newCRT(40, 6)
-	sprite := crt.drawSprite(cpu)
+	sprite := crt.drawSprite()
+	letters, err := crt.readLetters()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the screen: %s\n", err)
+		fmt.Printf(
+			"[Part Two] The answer is:\n%s\n",
+			sprite,
+		)
+		return
+	}
+
+	if *printScreen {
+		fmt.Println(sprite)
+	}
+
 	fmt.Printf(
-		"[Part Two] The answer is:\n%s\n",
-		sprite,
+		"[Part Two] The answer is: %s\n",
+		letters,
 	)
 }
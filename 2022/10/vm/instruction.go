@@ -0,0 +1,208 @@
+// Package vm implements a small cycle-accurate virtual machine whose
+// instructions are defined in a table rather than hard-coded.
+package vm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// ArgKind is the kind of value an instruction argument accepts.
+type ArgKind int
+
+const (
+	// Immediate is an integer literal.
+	Immediate ArgKind = iota
+	// Register is the name of a register.
+	Register
+)
+
+// String returns the string representation of the argument kind.
+func (k ArgKind) String() string {
+	switch k {
+	case Immediate:
+		return "immediate"
+	case Register:
+		return "register"
+	}
+	return ""
+}
+
+// Registers maps register names to their values.
+type Registers map[string]int
+
+// copy returns a snapshot of the registers.
+func (r Registers) copy() Registers {
+	snapshot := make(Registers, len(r))
+
+	for name, value := range r {
+		snapshot[name] = value
+	}
+
+	return snapshot
+}
+
+// Operand is a parsed instruction argument.
+type Operand struct {
+	Kind     ArgKind
+	Register string
+	Value    int
+}
+
+// Get returns the value of the operand, reading the register if needed.
+func (o Operand) Get(r Registers) int {
+	if o.Kind == Register {
+		return r[o.Register]
+	}
+
+	return o.Value
+}
+
+// String returns the string representation of the operand.
+func (o Operand) String() string {
+	if o.Kind == Register {
+		return o.Register
+	}
+
+	return strconv.Itoa(o.Value)
+}
+
+// Definition describes an instruction: its name, how many cycles it takes and
+// the effect it has on the registers once it completes.
+type Definition struct {
+	Name   string
+	Cycles int
+	Args   []ArgKind
+	Effect func(r Registers, args []Operand)
+}
+
+// Instruction is a parsed instruction ready to be executed.
+type Instruction struct {
+	Definition *Definition
+	Operands   []Operand
+	Line       int
+}
+
+// String returns the string representation of the instruction.
+func (i Instruction) String() string {
+	parts := []string{i.Definition.Name}
+
+	for _, operand := range i.Operands {
+		parts = append(parts, operand.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// InstructionSet is a table of instruction definitions over a fixed set of
+// registers.
+type InstructionSet struct {
+	definitions map[string]*Definition
+	registers   []string
+}
+
+// NewInstructionSet returns an empty instruction set using the given
+// registers.
+func NewInstructionSet(registers ...string) *InstructionSet {
+	return &InstructionSet{make(map[string]*Definition), registers}
+}
+
+// Registers returns the names of the registers of the instruction set.
+func (s *InstructionSet) Registers() []string {
+	return s.registers
+}
+
+// hasRegister checks whether a register belongs to the instruction set.
+func (s *InstructionSet) hasRegister(name string) bool {
+	for _, register := range s.registers {
+		if register == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Define adds an instruction to the set.
+func (s *InstructionSet) Define(definition Definition) error {
+	if definition.Name == "" {
+		return fmt.Errorf("instruction without a name")
+	}
+
+	if definition.Cycles < 1 {
+		return fmt.Errorf("instruction %q must take at least one cycle", definition.Name)
+	}
+
+	if _, ok := s.definitions[definition.Name]; ok {
+		return fmt.Errorf("instruction %q is already defined", definition.Name)
+	}
+
+	s.definitions[definition.Name] = &definition
+
+	return nil
+}
+
+// Parse parses a single line of assembly, rejecting unknown instructions and
+// arguments of the wrong kind.
+func (s *InstructionSet) Parse(line string) (Instruction, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Instruction{}, fmt.Errorf("empty instruction")
+	}
+
+	definition, ok := s.definitions[fields[0]]
+	if !ok {
+		return Instruction{}, fmt.Errorf("unknown instruction %q", fields[0])
+	}
+
+	args := fields[1:]
+	if len(args) != len(definition.Args) {
+		return Instruction{}, fmt.Errorf(
+			"%s takes %d argument(s), got %d", definition.Name, len(definition.Args), len(args),
+		)
+	}
+
+	operands := make([]Operand, len(args))
+	for i, arg := range args {
+		switch definition.Args[i] {
+		case Immediate:
+			value, err := strconv.Atoi(arg)
+			if err != nil {
+				return Instruction{}, fmt.Errorf("%s: invalid integer %q", definition.Name, arg)
+			}
+			operands[i] = Operand{Kind: Immediate, Value: value}
+		case Register:
+			if !s.hasRegister(arg) {
+				return Instruction{}, fmt.Errorf("%s: unknown register %q", definition.Name, arg)
+			}
+			operands[i] = Operand{Kind: Register, Register: arg}
+		}
+	}
+
+	return Instruction{definition, operands, 0}, nil
+}
+
+// ParseProgram parses every non-empty line, reporting the line number of the
+// first invalid instruction.
+func (s *InstructionSet) ParseProgram(lines []string) ([]Instruction, error) {
+	var program []Instruction
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		instruction, err := s.Parse(line)
+		if err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
+		}
+
+		instruction.Line = i + 1
+		program = append(program, instruction)
+	}
+
+	return program, nil
+}
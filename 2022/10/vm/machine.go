@@ -0,0 +1,143 @@
+package vm
+
+import "fmt"
+
+// Event describes a single cycle of the machine. The registers hold the values
+// seen during the cycle, before the instruction's effect is applied.
+type Event struct {
+	Cycle       int
+	Registers   Registers
+	Instruction Instruction
+	Step        int
+}
+
+// Completes checks whether the instruction's effect is applied at the end of
+// the cycle.
+func (e Event) Completes() bool {
+	return e.Step == e.Instruction.Definition.Cycles
+}
+
+// Breakpoint stops the machine before a cycle for which Matches is true.
+type Breakpoint struct {
+	Description string
+	Matches     func(cycle int, r Registers) bool
+}
+
+// AtCycle returns a breakpoint that stops before the given cycle.
+func AtCycle(cycle int) Breakpoint {
+	return Breakpoint{
+		fmt.Sprintf("cycle %d", cycle),
+		func(c int, r Registers) bool { return c == cycle },
+	}
+}
+
+// OnRegister returns a breakpoint that stops before any cycle during which a
+// register holds the given value.
+func OnRegister(register string, value int) Breakpoint {
+	return Breakpoint{
+		fmt.Sprintf("%s = %d", register, value),
+		func(c int, r Registers) bool { return r[register] == value },
+	}
+}
+
+// BreakpointError is returned by Run when a breakpoint stops the machine.
+type BreakpointError struct {
+	Breakpoint Breakpoint
+	Cycle      int
+	Registers  Registers
+}
+
+// Error returns the description of the breakpoint that was hit.
+func (e *BreakpointError) Error() string {
+	return fmt.Sprintf("breakpoint %s hit before cycle %d", e.Breakpoint.Description, e.Cycle)
+}
+
+// Machine executes a program one cycle at a time.
+type Machine struct {
+	Registers   Registers
+	Cycle       int
+	program     []Instruction
+	pc          int
+	step        int
+	subscribers []func(Event)
+	breakpoints []Breakpoint
+	pausedAt    int
+}
+
+// NewMachine returns a machine ready to run the program, with every register
+// of the instruction set set to zero.
+func NewMachine(set *InstructionSet, program []Instruction) *Machine {
+	registers := make(Registers)
+
+	for _, register := range set.Registers() {
+		registers[register] = 0
+	}
+
+	return &Machine{
+		Registers: registers,
+		program:   program,
+	}
+}
+
+// Subscribe registers a function to be called for every cycle executed.
+func (m *Machine) Subscribe(subscriber func(Event)) {
+	m.subscribers = append(m.subscribers, subscriber)
+}
+
+// AddBreakpoint registers a breakpoint checked before every cycle.
+func (m *Machine) AddBreakpoint(breakpoint Breakpoint) {
+	m.breakpoints = append(m.breakpoints, breakpoint)
+}
+
+// Halted checks whether the whole program has been executed.
+func (m *Machine) Halted() bool {
+	return m.pc >= len(m.program)
+}
+
+// Step executes a single cycle and returns false if the machine has halted.
+func (m *Machine) Step() bool {
+	if m.Halted() {
+		return false
+	}
+
+	instruction := m.program[m.pc]
+	m.Cycle++
+	m.step++
+
+	event := Event{m.Cycle, m.Registers.copy(), instruction, m.step}
+	for _, subscriber := range m.subscribers {
+		subscriber(event)
+	}
+
+	if event.Completes() {
+		if instruction.Definition.Effect != nil {
+			instruction.Definition.Effect(m.Registers, instruction.Operands)
+		}
+
+		m.pc++
+		m.step = 0
+	}
+
+	return true
+}
+
+// Run executes cycles until the program ends or a breakpoint is hit, in which
+// case a *BreakpointError is returned. Calling Run again resumes execution.
+func (m *Machine) Run() error {
+	for !m.Halted() {
+		next := m.Cycle + 1
+
+		if m.pausedAt != next {
+			for _, breakpoint := range m.breakpoints {
+				if breakpoint.Matches(next, m.Registers) {
+					m.pausedAt = next
+					return &BreakpointError{breakpoint, next, m.Registers.copy()}
+				}
+			}
+		}
+
+		m.Step()
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Tracer records every cycle of a machine it is subscribed to.
+type Tracer struct {
+	Events []Event
+}
+
+// Record stores an event, it is meant to be passed to Machine.Subscribe.
+func (t *Tracer) Record(event Event) {
+	t.Events = append(t.Events, event)
+}
+
+// formatRegisters returns the registers sorted by name, e.g. "X=1 Y=2".
+func formatRegisters(r Registers) string {
+	names := make([]string, 0, len(r))
+
+	for name := range r {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%d", name, r[name])
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Dump writes one line per recorded cycle with the instruction being executed
+// and the register values during that cycle.
+func (t *Tracer) Dump(w io.Writer) error {
+	for _, event := range t.Events {
+		_, err := fmt.Fprintf(
+			w,
+			"cycle %4d  line %4d  %-12s (%d/%d)  %s\n",
+			event.Cycle,
+			event.Instruction.Line,
+			event.Instruction,
+			event.Step,
+			event.Instruction.Definition.Cycles,
+			formatRegisters(event.Registers),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
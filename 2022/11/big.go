@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// playKeepAwayBig plays the game with arbitrary-precision worry levels, so they
+// stay exact even when they would overflow an int. Worry levels may grow very
+// quickly, so this is only practical for a small number of rounds. It returns
+// the number of items inspected by each monkey and leaves the monkeys
+// untouched.
+func playKeepAwayBig(monkeys []*Monkey, numOfRounds int, reliefDivisor int) ([]int, error) {
+	items := make([][]*big.Int, len(monkeys))
+	itemsInspected := make([]int, len(monkeys))
+	divisor := big.NewInt(int64(reliefDivisor))
+	remainder := new(big.Int)
+
+	for i, monkey := range monkeys {
+		for _, item := range monkey.StartingItems {
+			items[i] = append(items[i], big.NewInt(int64(item)))
+		}
+	}
+
+	for round := 0; round < numOfRounds; round++ {
+		for i, monkey := range monkeys {
+			for _, item := range items[i] {
+				itemsInspected[i]++
+
+				newWorryLevel, err := monkey.Operation.evalBig(item)
+				if err != nil {
+					return nil, fmt.Errorf("round %d, monkey %d: %w", round+1, i, err)
+				}
+
+				if reliefDivisor > 0 {
+					newWorryLevel = new(big.Int).Quo(newWorryLevel, divisor)
+				}
+
+				remainder.Rem(newWorryLevel, big.NewInt(int64(monkey.DivisibleBy)))
+
+				throwToMonkey := monkey.IfFalse()
+				if remainder.Sign() == 0 {
+					throwToMonkey = monkey.IfTrue()
+				}
+
+				items[throwToMonkey] = append(items[throwToMonkey], newWorryLevel)
+			}
+
+			items[i] = nil
+		}
+	}
+
+	return itemsInspected, nil
+}
+
+// calculateMonkeyBusinessFromCounts multiplies the highest inspection counts.
+func calculateMonkeyBusinessFromCounts(itemsInspected []int) int {
+	monkeys := make([]*Monkey, len(itemsInspected))
+
+	for i, count := range itemsInspected {
+		monkeys[i] = &Monkey{ItemsInspected: count}
+	}
+
+	return calculateMonkeyBusiness(getMostActiveMonkeys(monkeys))
+}
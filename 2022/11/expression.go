@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrOverflow is returned when a result does not fit in an int. The wrapped
+// result is returned along with it.
+var ErrOverflow = errors.New("integer overflow")
+
+// ErrDivisionByZero is returned when an expression divides by zero.
+var ErrDivisionByZero = errors.New("division by zero")
+
+// Expression is an arithmetic expression of the old worry level.
+type Expression interface {
+	eval(old int) (int, error)
+	evalBig(old *big.Int) (*big.Int, error)
+	hasDivision() bool
+	String() string
+}
+
+// oldExpression is the old worry level.
+type oldExpression struct{}
+
+func (e oldExpression) eval(old int) (int, error)              { return old, nil }
+func (e oldExpression) evalBig(old *big.Int) (*big.Int, error) { return old, nil }
+func (e oldExpression) hasDivision() bool                      { return false }
+func (e oldExpression) String() string                         { return OLD_VALUE }
+
+// literalExpression is an integer constant.
+type literalExpression struct {
+	value int
+}
+
+func (e literalExpression) eval(old int) (int, error) { return e.value, nil }
+func (e literalExpression) hasDivision() bool         { return false }
+func (e literalExpression) String() string            { return strconv.Itoa(e.value) }
+
+func (e literalExpression) evalBig(old *big.Int) (*big.Int, error) {
+	return big.NewInt(int64(e.value)), nil
+}
+
+// binaryExpression applies an operation to two expressions.
+type binaryExpression struct {
+	operation Operation
+	left      Expression
+	right     Expression
+}
+
+// String returns the expression fully parenthesized.
+func (e binaryExpression) String() string {
+	return fmt.Sprintf("(%s %s %s)", e.left, e.operation, e.right)
+}
+
+// hasDivision checks whether the expression divides at any point.
+func (e binaryExpression) hasDivision() bool {
+	return e.operation == DIVIDE || e.left.hasDivision() || e.right.hasDivision()
+}
+
+// eval evaluates the expression with machine integers. On overflow the
+// wrapped result is returned together with ErrOverflow.
+func (e binaryExpression) eval(old int) (int, error) {
+	left, leftErr := e.left.eval(old)
+	if leftErr != nil && !errors.Is(leftErr, ErrOverflow) {
+		return 0, leftErr
+	}
+
+	right, rightErr := e.right.eval(old)
+	if rightErr != nil && !errors.Is(rightErr, ErrOverflow) {
+		return 0, rightErr
+	}
+
+	result, err := applyInt(e.operation, left, right)
+	if err == nil && (leftErr != nil || rightErr != nil) {
+		err = ErrOverflow
+	}
+
+	return result, err
+}
+
+// evalBig evaluates the expression with arbitrary precision.
+func (e binaryExpression) evalBig(old *big.Int) (*big.Int, error) {
+	left, err := e.left.evalBig(old)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := e.right.evalBig(old)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(big.Int)
+
+	switch e.operation {
+	case ADD:
+		return result.Add(left, right), nil
+	case SUBTRACT:
+		return result.Sub(left, right), nil
+	case MULTIPLY:
+		return result.Mul(left, right), nil
+	case DIVIDE:
+		if right.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+		// truncated division, like Go's integer division
+		return result.Quo(left, right), nil
+	}
+
+	return nil, fmt.Errorf("unknown operation %d", e.operation)
+}
+
+// applyInt applies an operation to two ints, detecting overflows.
+func applyInt(operation Operation, a, b int) (int, error) {
+	switch operation {
+	case ADD:
+		result := a + b
+		if (a > 0 && b > 0 && result < 0) || (a < 0 && b < 0 && result >= 0) {
+			return result, ErrOverflow
+		}
+		return result, nil
+	case SUBTRACT:
+		result := a - b
+		if (b > 0 && result > a) || (b < 0 && result < a) {
+			return result, ErrOverflow
+		}
+		return result, nil
+	case MULTIPLY:
+		result := a * b
+		if a != 0 && (result/a != b || (a == -1 && b == math.MinInt)) {
+			return result, ErrOverflow
+		}
+		return result, nil
+	case DIVIDE:
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		if a == math.MinInt && b == -1 {
+			return a / b, ErrOverflow
+		}
+		return a / b, nil
+	}
+
+	return 0, fmt.Errorf("unknown operation %d", operation)
+}
+
+// expressionParser is a recursive descent parser for worry level expressions
+// made of "old", integers, + - * / and parentheses, with the usual precedence.
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+// tokenizeExpression splits an expression into tokens.
+func tokenizeExpression(input string) ([]string, error) {
+	var tokens []string
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("+-*/()", c):
+			tokens = append(tokens, string(c))
+			i++
+		case unicode.IsDigit(c) || unicode.IsLetter(c):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("unexpected character %q in expression", c)
+		}
+	}
+
+	return tokens, nil
+}
+
+// parseExpression parses an expression such as "old * old + 3".
+func parseExpression(input string) (Expression, error) {
+	tokens, err := tokenizeExpression(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens, 0}
+	expression, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in expression", p.tokens[p.pos])
+	}
+
+	return expression, nil
+}
+
+// peek returns the next token, or an empty string at the end.
+func (p *expressionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+// parseSum parses terms separated by + or -.
+func (p *expressionParser) parseSum() (Expression, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "+" || p.peek() == "-" {
+		operation := getOperationFromString(p.peek())
+		p.pos++
+
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryExpression{operation, left, right}
+	}
+
+	return left, nil
+}
+
+// parseProduct parses factors separated by * or /.
+func (p *expressionParser) parseProduct() (Expression, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "*" || p.peek() == "/" {
+		operation := getOperationFromString(p.peek())
+		p.pos++
+
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+
+		if literal, ok := right.(literalExpression); ok && operation == DIVIDE && literal.value == 0 {
+			return nil, ErrDivisionByZero
+		}
+
+		left = binaryExpression{operation, left, right}
+	}
+
+	return left, nil
+}
+
+// parseFactor parses "old", an integer, a negated factor or a parenthesized
+// expression.
+func (p *expressionParser) parseFactor() (Expression, error) {
+	token := p.peek()
+	p.pos++
+
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == OLD_VALUE:
+		return oldExpression{}, nil
+	case token == "-":
+		factor, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return binaryExpression{SUBTRACT, literalExpression{0}, factor}, nil
+	case token == "(":
+		expression, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expression, nil
+	}
+
+	value, err := strconv.Atoi(token)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected %q in expression", token)
+	}
+
+	return literalExpression{value}, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// ItemState is where an item is, and how worrying it is, at the start of a
+// round. Without relief, worry levels can be kept modulo the LCM of the
+// divisors, so an item can only be in a finite number of states.
+type ItemState struct {
+	Monkey     int
+	WorryLevel int
+}
+
+// playRoundForItem moves a single item through one round, adding the
+// inspections to the counts. An item thrown to a monkey that plays later in
+// the same round is inspected again before the round ends.
+func playRoundForItem(monkeys []*Monkey, state ItemState, lcm int, itemsInspected []int) (ItemState, error) {
+	for {
+		monkey := monkeys[state.Monkey]
+		itemsInspected[state.Monkey]++
+
+		newWorryLevel, err := monkey.Operation.eval(state.WorryLevel)
+		if err != nil {
+			return state, err
+		}
+
+		newWorryLevel = helpers.EuclideanRemainder(newWorryLevel, lcm)
+
+		throwToMonkey := monkey.IfFalse()
+		if monkey.Test(newWorryLevel) {
+			throwToMonkey = monkey.IfTrue()
+		}
+
+		done := throwToMonkey < state.Monkey
+		state = ItemState{throwToMonkey, newWorryLevel}
+
+		if done {
+			return state, nil
+		}
+	}
+}
+
+// simulateItem follows a single item for a number of rounds and returns how
+// many times each monkey inspects it. Once the item is back in a state it has
+// been in before, its trajectory repeats, so the remaining rounds are
+// extrapolated from the cycle instead of being played.
+func simulateItem(monkeys []*Monkey, start ItemState, numOfRounds int, lcm int) ([]int, error) {
+	numMonkeys := len(monkeys)
+	seen := map[ItemState]int{start: 0}
+	// prefix[r*numMonkeys+m] is the number of inspections by monkey m in the
+	// first r rounds
+	prefix := make([]int, numMonkeys, numMonkeys*16)
+	counts := make([]int, numMonkeys)
+	state := start
+
+	for round := 1; round <= numOfRounds; round++ {
+		var err error
+		state, err = playRoundForItem(monkeys, state, lcm, counts)
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", round, err)
+		}
+
+		prefix = append(prefix, counts...)
+
+		cycleStart, ok := seen[state]
+		if !ok {
+			seen[state] = round
+			continue
+		}
+
+		// the rounds from cycleStart to round repeat until the end
+		cycleLength := round - cycleStart
+		remainingRounds := numOfRounds - round
+		numOfCycles := remainingRounds / cycleLength
+		leftoverRounds := remainingRounds % cycleLength
+
+		for m := 0; m < numMonkeys; m++ {
+			startCount := prefix[cycleStart*numMonkeys+m]
+			perCycle := counts[m] - startCount
+			leftover := prefix[(cycleStart+leftoverRounds)*numMonkeys+m] - startCount
+
+			if perCycle > 0 && numOfCycles > (math.MaxInt-counts[m]-leftover)/perCycle {
+				return nil, fmt.Errorf("monkey %d inspects the item too many times to count", m)
+			}
+
+			counts[m] += numOfCycles*perCycle + leftover
+		}
+
+		return counts, nil
+	}
+
+	return counts, nil
+}
+
+// playKeepAwayPerItem plays the game without relief by following every item
+// independently and concurrently. It returns the number of items inspected by
+// each monkey and leaves the monkeys untouched.
+func playKeepAwayPerItem(monkeys []*Monkey, numOfRounds int, lcm int) ([]int, error) {
+	if anyDivides(monkeys) {
+		return nil, fmt.Errorf("items cannot be followed modulo the LCM when operations divide")
+	}
+
+	var states []ItemState
+	for i, monkey := range monkeys {
+		for _, item := range monkey.StartingItems {
+			states = append(states, ItemState{i, helpers.EuclideanRemainder(item, lcm)})
+		}
+	}
+
+	itemsInspected := make([]int, len(monkeys))
+	jobs := make(chan ItemState)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for state := range jobs {
+				counts, err := simulateItem(monkeys, state, numOfRounds, lcm)
+
+				mutex.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("item %d held by monkey %d: %w", state.WorryLevel, state.Monkey, err)
+				}
+				for m, count := range counts {
+					if itemsInspected[m] > math.MaxInt-count && firstErr == nil {
+						firstErr = fmt.Errorf("monkey %d inspects items too many times to count", m)
+					}
+					itemsInspected[m] += count
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, state := range states {
+		jobs <- state
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return itemsInspected, firstErr
+}
+
+// calculateMonkeyBusinessBig multiplies the highest inspection counts with
+// arbitrary precision, since they can be very large after many rounds.
+func calculateMonkeyBusinessBig(itemsInspected []int) *big.Int {
+	monkeys := make([]*Monkey, len(itemsInspected))
+
+	for i, count := range itemsInspected {
+		monkeys[i] = &Monkey{ItemsInspected: count}
+	}
+
+	total := big.NewInt(1)
+	for _, monkey := range getMostActiveMonkeys(monkeys) {
+		total.Mul(total, big.NewInt(int64(monkey.ItemsInspected)))
+	}
+
+	return total
+}
@@ -1,20 +1,20 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"math"
-	"regexp"
+	"os"
 	"sort"
-	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
 const VERBOSE = false
 const LESS_VERBOSE = false
-const NUM_OF_LINES_PER_MONKEY = 6
-const STARTING_ITEMS = "Starting items: "
+const FIELD_SEPARATOR = ":"
 const STARTING_ITEMS_DELIMITER = ", "
 const OLD_VALUE = "old"
 const NUM_OF_ROUNDS_PART_1 = 20
@@ -22,13 +22,24 @@ const NUM_OF_ROUNDS_PART_2 = 10000
 const RELIEF_DIVISOR_PART_1 = 3
 const RELIEF_DIVISOR_PART_2 = 0
 const NUM_MOST_ACTIVE_MONKEYS = 2
+const MONKEY_PATTERN = "Monkey {num:int}:"
+const STARTING_ITEMS_PATTERN = "Starting items:{items:[]int}"
+const OPERATION_PATTERN = "Operation: new = {expression}"
+const TEST_PATTERN = "Test: divisible by {divisor:int}"
+const IF_CONDITION_PATTERN = "If {condition}: throw to monkey {monkey:int}"
+
+var useBigInts = flag.Bool("big", false, "use arbitrary-precision worry levels in part one")
+var usePerItem = flag.Bool("per-item", false, "follow every item independently in part two, skipping repeated cycles")
+var numOfRoundsPart2 = flag.Int("rounds", NUM_OF_ROUNDS_PART_2, "number of rounds to play in part two")
 
 // Operation is an enum that represents the operation.
 type Operation int
 
 const (
 	ADD Operation = iota
+	SUBTRACT
 	MULTIPLY
+	DIVIDE
 )
 
 // String returns the string representation of the operation.
@@ -36,8 +47,12 @@ func (o Operation) String() string {
 	switch o {
 	case ADD:
 		return "+"
+	case SUBTRACT:
+		return "-"
 	case MULTIPLY:
 		return "*"
+	case DIVIDE:
+		return "/"
 	}
 	return ""
 }
@@ -47,15 +62,16 @@ func getOperationFromString(operation string) Operation {
 	switch operation {
 	case "+":
 		return ADD
+	case "-":
+		return SUBTRACT
 	case "*":
 		return MULTIPLY
+	case "/":
+		return DIVIDE
 	}
 	return -1
 }
 
-// OperationFn is a function that takes a worry level and returns a new worry level.
-type OperationFn func (worryLevel int) int
-
 // TestFn is a function that takes a worry level and returns a boolean.
 type TestFn func (worryLevel int) bool
 
@@ -65,12 +81,13 @@ type IfConditionFn func () int
 // Monkey represents a monkey.
 type Monkey struct {
 	StartingItems  []int
-	Operation      OperationFn
+	Operation      Expression
 	Test           TestFn
 	IfTrue         IfConditionFn
 	IfFalse        IfConditionFn
 	ItemsInspected int
 	DivisibleBy    int
+	Overflowed     bool
 }
 
 // String returns the string representation of the monkey.
@@ -81,23 +98,32 @@ func (m Monkey) String() string {
 }
 
 // inspect inspects the worry level item and returns a new worry level.
-func (m *Monkey) inspect(item int) int {
+func (m *Monkey) inspect(item int) (int, error) {
 	m.ItemsInspected++
 
-	return m.Operation(item)
+	newWorryLevel, err := m.Operation.eval(item)
+	if errors.Is(err, ErrOverflow) {
+		m.Overflowed = true
+		return newWorryLevel, nil
+	}
+
+	return newWorryLevel, err
 }
 
 // play plays the game for a single monkey.
-func (m *Monkey) play(monkeys []*Monkey, reliefDivisor int, lcm int) {
+func (m *Monkey) play(monkeys []*Monkey, reliefDivisor int, lcm int) error {
 	for _, item := range m.StartingItems {
 		// Monkey inspects the worry level item
-		newWorryLevel := m.inspect(item)
+		newWorryLevel, err := m.inspect(item)
+		if err != nil {
+			return err
+		}
 		// Monkey gets bored
 		var adjustedNewWorryLevel int
 		if reliefDivisor > 0 {
 			adjustedNewWorryLevel = newWorryLevel / reliefDivisor
-		} else if (newWorryLevel > lcm) {
-			adjustedNewWorryLevel = newWorryLevel % lcm
+		} else if lcm > 0 {
+			adjustedNewWorryLevel = helpers.EuclideanRemainder(newWorryLevel, lcm)
 		} else {
 			adjustedNewWorryLevel = newWorryLevel
 		}
@@ -118,76 +144,85 @@ func (m *Monkey) play(monkeys []*Monkey, reliefDivisor int, lcm int) {
 		// Monkey gets rid of the worry level item
 		m.StartingItems = m.StartingItems[1:]
 	}
+
+	return nil
+}
+
+// getField returns the name of the field a line defines, e.g. "Test".
+func getField(line string) string {
+	return strings.SplitN(line, FIELD_SEPARATOR, 2)[0]
 }
 
 // getMonkeyNum returns the monkey number from a line.
-func getMonkeyNum(line string) int {
-	re := regexp.MustCompile(`Monkey (\d+):`)
-	matches := re.FindStringSubmatch(line)
-	monkeyNum, _ := strconv.Atoi(matches[1])
+func getMonkeyNum(line string) (int, error) {
+	var monkey struct{ Num int }
+	err := helpers.MustCompilePattern(MONKEY_PATTERN).Unmarshal(line, &monkey)
 
-	return monkeyNum
+	return monkey.Num, err
 }
 
 // getStartingItems returns the starting items from a line.
-func getStartingItems(line string) []int {
-	startingItemsSlice := strings.Split(line, STARTING_ITEMS)
-	startingItemsString := startingItemsSlice[1]
-	startingItemsStringSlice := strings.Split(startingItemsString, STARTING_ITEMS_DELIMITER)
-	startingItems := make([]int, len(startingItemsStringSlice))
-
-	for i, startingItemString := range startingItemsStringSlice {
-		startingItem, _ := strconv.Atoi(startingItemString)
-		startingItems[i] = startingItem
+func getStartingItems(line string) ([]int, error) {
+	var startingItems struct{ Items []int }
+	if err := helpers.MustCompilePattern(STARTING_ITEMS_PATTERN).Unmarshal(line, &startingItems); err != nil {
+		return nil, err
 	}
 
-	return startingItems
+	return startingItems.Items, nil
 }
 
 // getOperation returns the operation from a line.
-func getOperation(line string) OperationFn {
-	re := regexp.MustCompile(`Operation: new = old ([\+|\*]) (\d+|\w+)`)
-	matches := re.FindStringSubmatch(line)
-	operation := getOperationFromString(matches[1])
-	operandString := matches[2]
-	operand, _ := strconv.Atoi(operandString)
-
-	return func (worryLevel int) int {
-		if operandString == OLD_VALUE {
-			operand = worryLevel
-		}
-
-		switch operation {
-		case ADD:
-			return worryLevel + operand
-		case MULTIPLY:
-			return worryLevel * operand
-		}
+func getOperation(line string) (Expression, error) {
+	var operation struct{ Expression string }
+	if err := helpers.MustCompilePattern(OPERATION_PATTERN).Unmarshal(line, &operation); err != nil {
+		return nil, err
+	}
 
-		return worryLevel
+	expression, err := parseExpression(operation.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid operation %q: %w", operation.Expression, err)
 	}
+
+	return expression, nil
 }
 
 // getTest returns the test from a line.
-func getTest(line string) (int, TestFn) {
-	re := regexp.MustCompile(`Test: divisible by (\d+)`)
-	matches := re.FindStringSubmatch(line)
-	divisible, _ := strconv.Atoi(matches[1])
+func getTest(line string) (int, TestFn, error) {
+	var test struct{ Divisor int }
+	if err := helpers.MustCompilePattern(TEST_PATTERN).Unmarshal(line, &test); err != nil {
+		return 0, nil, err
+	}
+
+	divisible := test.Divisor
+	if divisible == 0 {
+		return 0, nil, fmt.Errorf("invalid divisor %d", divisible)
+	}
 
 	return divisible, func (worryLevel int) bool {
 		return worryLevel % divisible == 0
-	}
+	}, nil
 }
 
-// getIfCondition returns the if condition from a line.
-func getIfCondition(line string) IfConditionFn {
-	re := regexp.MustCompile(`If (true|false): throw to monkey (\d+)`)
-	matches := re.FindStringSubmatch(line)
-	monkey, _ := strconv.Atoi(matches[2])
+// getIfCondition returns the if condition from a line, along with the number
+// of the monkey it throws to.
+func getIfCondition(line string, condition string) (int, IfConditionFn, error) {
+	var ifCondition struct {
+		Condition string
+		Monkey    int
+	}
+	if err := helpers.MustCompilePattern(IF_CONDITION_PATTERN).Unmarshal(line, &ifCondition); err != nil {
+		return 0, nil, err
+	}
 
-	return func () int {
-		return monkey
+	if ifCondition.Condition != condition {
+		return 0, nil, fmt.Errorf("expected \"If %s: throw to monkey <number>\"", condition)
 	}
+
+	monkey := ifCondition.Monkey
+
+	return monkey, func () int {
+		return monkey
+	}, nil
 }
 
 // calculateMonkeyBusiness calculates the monkey business.
@@ -222,7 +257,7 @@ func getMonkeyDivisors(monkeys []*Monkey) []int {
 }
 
 // playKeepAway plays the game.
-func playKeepAway(monkeys []*Monkey, numOfRounds, reliefDivisor int, lcm int) {
+func playKeepAway(monkeys []*Monkey, numOfRounds, reliefDivisor int, lcm int) error {
 	for i := 0; i < numOfRounds; i++ {
 		if VERBOSE || LESS_VERBOSE {
 			fmt.Println("Round", i + 1)
@@ -233,53 +268,202 @@ func playKeepAway(monkeys []*Monkey, numOfRounds, reliefDivisor int, lcm int) {
 				fmt.Print("- Monkey", j)
 			}
 
-			monkey.play(monkeys, reliefDivisor, lcm)
+			if err := monkey.play(monkeys, reliefDivisor, lcm); err != nil {
+				return fmt.Errorf("round %d, monkey %d: %w", i + 1, j, err)
+			}
 
 			if VERBOSE || LESS_VERBOSE {
 				fmt.Println(" inspected items", monkey.ItemsInspected, "times.")
 			}
 		}
 	}
+
+	return nil
 }
 
-// getMonkeysFromFile returns the monkeys from a file.
-func getMonkeysFromFile(txtlines []string) []*Monkey {
-	numLines := len(txtlines)
-	// get the number of monkeys, 1 is added to the number of lines to account for the empty line at the end of each monkey
-	numLinesToProcess := NUM_OF_LINES_PER_MONKEY + 1
-	numMonkeys := int(math.Round(float64(numLines) / float64(numLinesToProcess)))
-	monkeys := make([]*Monkey, numMonkeys)
-
-	// get the monkeys
-	for i := 0; i < numLines; i += numLinesToProcess {
-		// get the starting items
-		monkeyNum := getMonkeyNum(txtlines[i])
-		// get the starting items
-		startingItems := getStartingItems(txtlines[i + 1])
-		// get the operation
-		operation := getOperation(txtlines[i + 2])
-		// get the test
-		divisible, test := getTest(txtlines[i + 3])
-		// get the if true
-		ifTrue := getIfCondition(txtlines[i + 4])
-		// get the if false
-		ifFalse := getIfCondition(txtlines[i + 5])
-
-		// create the monkey
-		monkey := Monkey{
-			StartingItems: startingItems,
-			Operation:     operation,
-			Test:          test,
-			IfTrue:        ifTrue,
-			IfFalse:       ifFalse,
-			DivisibleBy:   divisible,
+// anyOverflowed checks whether any monkey's worry levels overflowed.
+func anyOverflowed(monkeys []*Monkey) bool {
+	for _, monkey := range monkeys {
+		if monkey.Overflowed {
+			return true
 		}
+	}
 
-		// add the monkey to the list
-		monkeys[monkeyNum] = &monkey
+	return false
+}
+
+// anyDivides checks whether any monkey's operation divides.
+func anyDivides(monkeys []*Monkey) bool {
+	for _, monkey := range monkeys {
+		if monkey.Operation.hasDivision() {
+			return true
+		}
 	}
 
-	return monkeys
+	return false
+}
+
+// getMonkeysFromFile returns the monkeys from a file. Monkeys are separated by
+// blank lines and their attributes may be given in any order.
+func getMonkeysFromFile(txtlines []string) ([]*Monkey, error) {
+	monkeysByNum := map[int]*Monkey{}
+	targets := map[int][]int{}
+	targetLines := map[int][]int{}
+	requiredFields := []string{"Starting items", "Operation", "Test", "If true", "If false"}
+
+	var monkey *Monkey
+	var monkeyNum, monkeyLine int
+	var fields map[string]bool
+
+	// finishMonkey checks that the current monkey is complete
+	finishMonkey := func() error {
+		if monkey == nil {
+			return nil
+		}
+
+		for _, field := range requiredFields {
+			if !fields[field] {
+				return helpers.NewParseError(monkeyLine, 0, txtlines[monkeyLine-1], "monkey %d is missing %q", monkeyNum, field)
+			}
+		}
+
+		monkeysByNum[monkeyNum] = monkey
+		monkey = nil
+
+		return nil
+	}
+
+	for i, rawLine := range txtlines {
+		lineNum := i + 1
+		line := strings.TrimSpace(rawLine)
+		indent := len(rawLine) - len(strings.TrimLeftFunc(rawLine, unicode.IsSpace))
+
+		if line == "" {
+			if err := finishMonkey(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "Monkey") {
+			if err := finishMonkey(); err != nil {
+				return nil, err
+			}
+
+			num, err := getMonkeyNum(line)
+			if err != nil {
+				return nil, helpers.AtLineOffset(err, lineNum, rawLine, indent)
+			}
+
+			if _, ok := monkeysByNum[num]; ok {
+				return nil, helpers.NewParseError(lineNum, 0, rawLine, "monkey %d is defined more than once", num)
+			}
+
+			monkey, monkeyNum, monkeyLine, fields = &Monkey{}, num, lineNum, map[string]bool{}
+			continue
+		}
+
+		if monkey == nil {
+			return nil, helpers.NewParseError(lineNum, 0, rawLine, "expected \"Monkey <number>:\"")
+		}
+
+		field := getField(line)
+		if fields[field] {
+			return nil, helpers.NewParseError(lineNum, 0, rawLine, "%q is given more than once for monkey %d", field, monkeyNum)
+		}
+		fields[field] = true
+
+		var err error
+		switch field {
+		case "Starting items":
+			monkey.StartingItems, err = getStartingItems(line)
+		case "Operation":
+			monkey.Operation, err = getOperation(line)
+		case "Test":
+			monkey.DivisibleBy, monkey.Test, err = getTest(line)
+		case "If true", "If false":
+			var target int
+			condition := strings.TrimPrefix(field, "If ")
+			if condition == "true" {
+				target, monkey.IfTrue, err = getIfCondition(line, condition)
+			} else {
+				target, monkey.IfFalse, err = getIfCondition(line, condition)
+			}
+			targets[monkeyNum] = append(targets[monkeyNum], target)
+			targetLines[monkeyNum] = append(targetLines[monkeyNum], lineNum)
+		default:
+			err = fmt.Errorf("unknown attribute %q", field)
+		}
+
+		if err != nil {
+			return nil, helpers.AtLineOffset(err, lineNum, rawLine, indent)
+		}
+	}
+
+	if err := finishMonkey(); err != nil {
+		return nil, err
+	}
+
+	// monkeys are numbered from zero and only throw to each other
+	monkeys := make([]*Monkey, len(monkeysByNum))
+	for num, monkey := range monkeysByNum {
+		if num < 0 || num >= len(monkeys) {
+			return nil, fmt.Errorf("monkeys must be numbered from 0 to %d, found monkey %d", len(monkeys) - 1, num)
+		}
+
+		for j, target := range targets[num] {
+			if target == num || monkeysByNum[target] == nil {
+				return nil, helpers.NewParseError(targetLines[num][j], 0, txtlines[targetLines[num][j]-1], "monkey %d cannot throw to monkey %d", num, target)
+			}
+		}
+
+		monkeys[num] = monkey
+	}
+
+	if len(monkeys) < NUM_MOST_ACTIVE_MONKEYS {
+		return nil, fmt.Errorf("expected at least %d monkeys, found %d", NUM_MOST_ACTIVE_MONKEYS, len(monkeys))
+	}
+
+	return monkeys, nil
+}
+
+// mustPlayKeepAway plays the game, exiting on errors.
+func mustPlayKeepAway(monkeys []*Monkey, numOfRounds, reliefDivisor int, lcm int) {
+	if err := playKeepAway(monkeys, numOfRounds, reliefDivisor, lcm); err != nil {
+		fmt.Fprintf(os.Stderr, "failed playing keep away: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// printMonkeys prints the items held and inspected by each monkey.
+func printMonkeys(monkeys []*Monkey) {
+	for i, monkey := range monkeys {
+		fmt.Println(
+			"Monkey",
+			i,
+			"has",
+			len(monkey.StartingItems),
+			"items: [",
+			monkey,
+			"], inspected",
+			monkey.ItemsInspected,
+			"times",
+		)
+	}
+}
+
+// copyMonkeys returns a copy of the monkeys that can play without changing
+// their items or counts.
+func copyMonkeys(monkeys []*Monkey) []*Monkey {
+	copied := make([]*Monkey, len(monkeys))
+
+	for i, monkey := range monkeys {
+		monkeyCopy := *monkey
+		monkeyCopy.StartingItems = append([]int{}, monkey.StartingItems...)
+		copied[i] = &monkeyCopy
+	}
+
+	return copied
 }
 
 // main is the entry point for the application.
@@ -289,54 +473,78 @@ func main() {
 	filename := args[0]
 	txtlines := helpers.ReadFile(filename)
 
+	// process the file
+	monkeys, err := getMonkeysFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing monkeys: %s\n", err)
+		os.Exit(1)
+	}
+
 	// part 1
-	monkeysA := getMonkeysFromFile(txtlines)
-	playKeepAway(monkeysA, NUM_OF_ROUNDS_PART_1, RELIEF_DIVISOR_PART_1, 0)
+	// the relief division rules out the LCM trick, so worry levels can only be
+	// kept exact with arbitrary precision
+	var monkeyBusinessA int
+	if *useBigInts {
+		itemsInspected, err := playKeepAwayBig(monkeys, NUM_OF_ROUNDS_PART_1, RELIEF_DIVISOR_PART_1)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed playing keep away: %s\n", err)
+			os.Exit(1)
+		}
 
-	if VERBOSE || LESS_VERBOSE {
-		for i, monkey := range monkeysA {
-			fmt.Println(
-				"Monkey",
-				i,
-				"has",
-				len(monkey.StartingItems),
-				"items: [",
-				monkey,
-				"], inspected",
-				monkey.ItemsInspected,
-				"times",
-			)
+		monkeyBusinessA = calculateMonkeyBusinessFromCounts(itemsInspected)
+	} else {
+		monkeysA := copyMonkeys(monkeys)
+		mustPlayKeepAway(monkeysA, NUM_OF_ROUNDS_PART_1, RELIEF_DIVISOR_PART_1, 0)
+
+		if VERBOSE || LESS_VERBOSE {
+			printMonkeys(monkeysA)
 		}
+
+		if anyOverflowed(monkeysA) {
+			fmt.Fprintln(os.Stderr, "warning: worry levels overflowed during part one, the answer is likely wrong (try -big)")
+		}
+
+		mostActiveMonkeysA := getMostActiveMonkeys(monkeysA)
+		monkeyBusinessA = calculateMonkeyBusiness(mostActiveMonkeysA)
 	}
 
-	mostActiveMonkeysA := getMostActiveMonkeys(monkeysA)
-	monkeyBusinessA := calculateMonkeyBusiness(mostActiveMonkeysA)
 	fmt.Printf(
 		"[Part One] The answer is: %d\n",
 		monkeyBusinessA,
 	)
 
 	// part 2
-	monkeysB := getMonkeysFromFile(txtlines)
 	// "(...) find another way to keep your worry levels manageable."
-	monkeyDivisors := getMonkeyDivisors(monkeysB)
+	monkeyDivisors := getMonkeyDivisors(monkeys)
 	lcm := helpers.FindLCM(monkeyDivisors)
-	playKeepAway(monkeysB, NUM_OF_ROUNDS_PART_2, RELIEF_DIVISOR_PART_2, lcm)
 
-	if VERBOSE || LESS_VERBOSE {
-		for i, monkey := range monkeysB {
-			fmt.Println(
-				"Monkey",
-				i,
-				"has",
-				len(monkey.StartingItems),
-				"items: [",
-				monkey,
-				"], inspected",
-				monkey.ItemsInspected,
-				"times",
-			)
+	if *usePerItem {
+		itemsInspected, err := playKeepAwayPerItem(monkeys, *numOfRoundsPart2, lcm)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed playing keep away: %s\n", err)
+			os.Exit(1)
 		}
+
+		fmt.Printf(
+			"[Part Two] The answer is: %s\n",
+			calculateMonkeyBusinessBig(itemsInspected),
+		)
+		return
+	}
+
+	if anyDivides(monkeys) {
+		fmt.Fprintln(os.Stderr, "warning: the operations divide, so reducing worry levels modulo the LCM may give a wrong answer")
+	}
+
+	monkeysB := copyMonkeys(monkeys)
+	mustPlayKeepAway(monkeysB, *numOfRoundsPart2, RELIEF_DIVISOR_PART_2, lcm)
+
+	if VERBOSE || LESS_VERBOSE {
+		printMonkeys(monkeysB)
+	}
+
+	if anyOverflowed(monkeysB) {
+		fmt.Fprintln(os.Stderr, "warning: worry levels overflowed during part two even modulo the LCM, the answer is likely wrong")
 	}
 
 	mostActiveMonkeysB := getMostActiveMonkeys(monkeysB)
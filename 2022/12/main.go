@@ -2,7 +2,9 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -16,6 +18,14 @@ const EDGE_LENGTH = 1
 const INFINITY = 999999
 const POINT_DELIMITER = ","
 
+var routeFrom = flag.String("from", "", "start the route at x,y instead of S")
+var routeTo = flag.String("to", "", "end the route at x,y instead of E")
+var printArrows = flag.Bool("arrows", false, "print the route as a map of arrows")
+var printColors = flag.Bool("color", false, "print the map coloured by height with the route on top")
+var routeSVG = flag.String("svg", "", "write the map and the route to this SVG file")
+var routePNG = flag.String("png", "", "write the map and the route to this PNG file")
+var imageScale = flag.Int("scale", 8, "size in pixels of each position in the SVG and PNG images")
+
 // Move represents a move in the map.
 type Move int
 
@@ -76,7 +86,7 @@ const (
 
 // String returns the string representation of the heightmap.
 func (h Heights) String() string {
-	return string('a' + h)
+	return string(rune('a' + h))
 }
 
 // convertToHeightmap converts a rune to a heightmap.
@@ -391,10 +401,15 @@ func (m *Mapheight) findPathAlgorithm() {
 }
 
 // findPathRoute finds the shortest path from the start to the end using the points' distance.
-func (m *Mapheight) findPathRoute() {
+func (m *Mapheight) findPathRoute() error {
 	m.route = append(m.route, m.end)
 	m.current = m.end
 
+	// the route is already complete when it ends where it starts
+	if m.current.x == m.start.x && m.current.y == m.start.y {
+		return nil
+	}
+
 	// find the smallest neighbour of the current point
 	smallest := INFINITY
 	var smallestPoint *Point
@@ -406,30 +421,40 @@ func (m *Mapheight) findPathRoute() {
 		}
 	}
 
+	if smallestPoint == nil {
+		return errors.New("there is no route to the end")
+	}
+
 	m.current = smallestPoint
+	m.route = append(m.route, smallestPoint)
+
+	for m.current.x != m.start.x || m.current.y != m.start.y {
+		var next *Point
 
-	for {
 		for _, neighbour := range m.getNeighbours() {
 			if m.current.distance == neighbour.distance + EDGE_LENGTH {
-				m.current = neighbour
-				m.route = append(m.route, neighbour)
-
-				if VERBOSE {
-					fmt.Printf("Added (%d%s%d) to the route\n", neighbour.x, POINT_DELIMITER, neighbour.y)
-				}
-
+				next = neighbour
 				break
 			}
 		}
 
-		if m.current.x == m.start.x && m.current.y == m.start.y {
-			break
+		if next == nil {
+			return errors.New("there is no route to the end")
+		}
+
+		m.current = next
+		m.route = append(m.route, next)
+
+		if VERBOSE {
+			fmt.Printf("Added (%d%s%d) to the route\n", next.x, POINT_DELIMITER, next.y)
 		}
 	}
+
+	return nil
 }
 
 // findPath finds the path from the start to the end using Dijkstra's algorithm.
-func (m *Mapheight) findPath() {
+func (m *Mapheight) findPath() error {
 	m.start.distance = 0
 	m.current = m.start
 	m.markVisited(m.end.x, m.end.y)
@@ -438,7 +463,28 @@ func (m *Mapheight) findPath() {
 
 	m.testFn = canGoDown
 
-	m.findPathRoute()
+	return m.findPathRoute()
+}
+
+// getSteps returns the number of steps of the route.
+func (m *Mapheight) getSteps() int {
+	return len(m.route) - 1
+}
+
+// getPointFromString returns the point at a position such as "3,4".
+func (m *Mapheight) getPointFromString(position string) (*Point, error) {
+	xy := strings.Split(position, POINT_DELIMITER)
+	if len(xy) != 2 {
+		return nil, fmt.Errorf("invalid position %q, expected x%sy", position, POINT_DELIMITER)
+	}
+
+	x, errX := strconv.Atoi(xy[0])
+	y, errY := strconv.Atoi(xy[1])
+	if errX != nil || errY != nil || y < 0 || y >= len(m.points) || x < 0 || x >= len(m.points[y]) {
+		return nil, fmt.Errorf("position %q is not on the map", position)
+	}
+
+	return m.getPoint(x, y), nil
 }
 
 // getPossibleStartingPoints returns the possible starting points.
@@ -501,23 +547,92 @@ func (m *Mapheight) String() string {
 	return result
 }
 
-// getMapheightsForStartingPoints returns the mapheights for the starting points.
-func getMapheightsForStartingPoints(lines []string, startingPoints []*Point) []int {
+// checkHeightmap checks that the lines are rows of the same length made of
+// heights from a to z, with at most one start and one end, which must be given
+// unless they're set some other way.
+func checkHeightmap(lines []string, needsStart bool, needsEnd bool) error {
+	if len(lines) == 0 || lines[0] == "" {
+		return fmt.Errorf("the heightmap is empty")
+	}
+
+	found := map[string]int{}
+
+	for y, line := range lines {
+		if len(line) != len(lines[0]) {
+			return helpers.NewParseError(y+1, 0, line, "expected %d heights, got %d", len(lines[0]), len(line))
+		}
+
+		for x, c := range line {
+			switch {
+			case string(c) == START || string(c) == END:
+				if found[string(c)] > 0 {
+					return helpers.NewParseError(y+1, x+1, line, "%s is already on line %d", string(c), found[string(c)])
+				}
+				found[string(c)] = y + 1
+			case c < 'a' || c > 'z':
+				return helpers.NewParseError(y+1, x+1, line, "invalid height %q", c)
+			}
+		}
+	}
+
+	if needsStart && found[START] == 0 {
+		return fmt.Errorf("the heightmap has no start %s", START)
+	}
+
+	if needsEnd && found[END] == 0 {
+		return fmt.Errorf("the heightmap has no end %s", END)
+	}
+
+	return nil
+}
+
+// getMapheightsForStartingPoints returns the length of the routes from each
+// of the starting points to the end, which is moved to the given position
+// unless it's empty.
+func getMapheightsForStartingPoints(lines []string, to string, startingPoints []*Point) ([]int, error) {
 	numPoints := len(startingPoints)
 	routeLengths := make([]int, numPoints)
 
 	for i, startingPoint := range startingPoints {
 		mapheight := newMapheight(lines)
+		if err := mapheight.setEndpoints("", to); err != nil {
+			return nil, err
+		}
 		point := mapheight.getPoint(startingPoint.x, startingPoint.y)
 		mapheight.start = point
 
-		mapheight.findPath()
+		if err := mapheight.findPath(); err != nil {
+			routeLengths[i] = INFINITY
+			continue
+		}
 		mapheight.clear()
 
-		routeLengths[i] = len(mapheight.route)
+		routeLengths[i] = mapheight.getSteps()
+	}
+
+	return routeLengths, nil
+}
+
+// setEndpoints moves the start and the end of the map to the given positions,
+// leaving them untouched when a position is empty.
+func (m *Mapheight) setEndpoints(from string, to string) error {
+	if from != "" {
+		point, err := m.getPointFromString(from)
+		if err != nil {
+			return err
+		}
+		m.start = point
+	}
+
+	if to != "" {
+		point, err := m.getPointFromString(to)
+		if err != nil {
+			return err
+		}
+		m.end = point
 	}
 
-	return routeLengths
+	return nil
 }
 
 // main is the entry point for the application.
@@ -531,13 +646,27 @@ func main() {
 	}
 	txtlines := helpers.ReadFile(filename)
 
+	if err := checkHeightmap(txtlines, *routeFrom == "", *routeTo == ""); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid heightmap: %s\n", err)
+		os.Exit(1)
+	}
+
 	// part 1
 	mapheight := newMapheight(txtlines)
-	mapheight.findPath()
+	if err := mapheight.setEndpoints(*routeFrom, *routeTo); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid endpoints: %s\n", err)
+		os.Exit(1)
+	}
+	if err := mapheight.findPath(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed finding the route: %s\n", err)
+		os.Exit(1)
+	}
 	if startingPoint == "" {
+		renderRoute(mapheight)
+
 		fmt.Printf(
 			"[Part One] The answer is: %d\n",
-			len(mapheight.route),
+			mapheight.getSteps(),
 		)
 		fmt.Println("[Part Two] The answer can be obtained with the following command:")
 		fmt.Printf("\n\tgo run . %s all\n\n", filename)
@@ -554,13 +683,22 @@ func main() {
 		fmt.Print(strings.Join(startingPoints, "\n"))
 	} else if startingPoint != "" {
 		// split and parse the starting point
-		xy := strings.Split(startingPoint, POINT_DELIMITER)
-		x, _ := strconv.Atoi(xy[0])
-		y, _ := strconv.Atoi(xy[1])
-		point := mapheight.getPoint(x, y)
+		point, err := mapheight.getPointFromString(startingPoint)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 		possibleStartingPoints = []*Point{point}
-		routeLengths := getMapheightsForStartingPoints(txtlines, possibleStartingPoints)
+		routeLengths, err := getMapheightsForStartingPoints(txtlines, *routeTo, possibleStartingPoints)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid endpoints: %s\n", err)
+			os.Exit(1)
+		}
 		shortestRoute := helpers.MinOf(routeLengths...)
+		if shortestRoute == INFINITY {
+			fmt.Fprintf(os.Stderr, "there is no route from %s\n", startingPoint)
+			os.Exit(1)
+		}
 		fmt.Println(shortestRoute)
 	}
 }
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+const ROUTE_EMPTY = '.'
+const ANSI_RESET = "\x1b[0m"
+
+// routeColor is the colour the route is drawn with in images.
+var routeColor = color.RGBA{220, 20, 60, 255}
+
+// getRoutePath returns the points of the route from the start to the end.
+func (m *Mapheight) getRoutePath() []*Point {
+	path := make([]*Point, len(m.route))
+
+	for i, point := range m.route {
+		path[len(m.route)-1-i] = point
+	}
+
+	return path
+}
+
+// getArrow returns the arrow pointing from one point to the next one.
+func getArrow(from, to *Point) rune {
+	switch {
+	case to.y < from.y:
+		return '^'
+	case to.y > from.y:
+		return 'v'
+	case to.x < from.x:
+		return '<'
+	}
+
+	return '>'
+}
+
+// getRouteMarkers returns the character to draw at each point of the route,
+// an arrow towards the next point or E for the end.
+func (m *Mapheight) getRouteMarkers() map[*Point]rune {
+	path := m.getRoutePath()
+	markers := make(map[*Point]rune, len(path))
+
+	for i, point := range path {
+		if i == len(path)-1 {
+			markers[point] = []rune(END)[0]
+		} else {
+			markers[point] = getArrow(point, path[i+1])
+		}
+	}
+
+	return markers
+}
+
+// renderArrows returns the route drawn with arrows, like the puzzle does.
+func (m *Mapheight) renderArrows() string {
+	var sb strings.Builder
+	markers := m.getRouteMarkers()
+
+	for y := range m.points {
+		for x := range m.points[y] {
+			marker, ok := markers[m.getPoint(x, y)]
+			if !ok {
+				marker = ROUTE_EMPTY
+			}
+			sb.WriteRune(marker)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// getHeightColor returns the colour of a height, going from green valleys
+// through brown slopes to snowy peaks.
+func getHeightColor(height Heights) color.RGBA {
+	stops := []color.RGBA{
+		{34, 102, 51, 255},
+		{153, 136, 68, 255},
+		{119, 85, 51, 255},
+		{245, 245, 245, 255},
+	}
+
+	position := float64(height) / float64(z) * float64(len(stops)-1)
+	i := int(position)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+
+	t := position - float64(i)
+	blend := func(from, to uint8) uint8 {
+		return uint8(float64(from) + (float64(to)-float64(from))*t)
+	}
+
+	return color.RGBA{
+		blend(stops[i].R, stops[i+1].R),
+		blend(stops[i].G, stops[i+1].G),
+		blend(stops[i].B, stops[i+1].B),
+		255,
+	}
+}
+
+// renderColors returns the map with every position coloured by its height
+// using ANSI escape codes, and the route drawn over it.
+func (m *Mapheight) renderColors() string {
+	var sb strings.Builder
+	markers := m.getRouteMarkers()
+
+	for y, row := range m.grid {
+		for x, height := range row {
+			background := getHeightColor(height)
+			sb.WriteString(fmt.Sprintf("\x1b[48;2;%d;%d;%dm", background.R, background.G, background.B))
+
+			if marker, ok := markers[m.getPoint(x, y)]; ok {
+				sb.WriteString(fmt.Sprintf("\x1b[1;38;2;%d;%d;%dm%c", routeColor.R, routeColor.G, routeColor.B, marker))
+			} else {
+				sb.WriteString("\x1b[22;38;2;0;0;0m" + height.String())
+			}
+		}
+		sb.WriteString(ANSI_RESET + "\n")
+	}
+
+	return sb.String()
+}
+
+// renderSVG returns the map as an SVG image with the route drawn over it.
+func (m *Mapheight) renderSVG(scale int) string {
+	var sb strings.Builder
+	height := len(m.grid)
+	width := 0
+	if height > 0 {
+		width = len(m.grid[0])
+	}
+
+	sb.WriteString(fmt.Sprintf(
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width*scale, height*scale, width*scale, height*scale,
+	))
+
+	for y, row := range m.grid {
+		for x, h := range row {
+			c := getHeightColor(h)
+			sb.WriteString(fmt.Sprintf(
+				"<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"#%02x%02x%02x\"><title>%s</title></rect>\n",
+				x*scale, y*scale, scale, scale, c.R, c.G, c.B, h,
+			))
+		}
+	}
+
+	points := []string{}
+	for _, point := range m.getRoutePath() {
+		points = append(points, fmt.Sprintf("%d,%d", point.x*scale+scale/2, point.y*scale+scale/2))
+	}
+
+	sb.WriteString(fmt.Sprintf(
+		"<polyline points=\"%s\" fill=\"none\" stroke=\"#%02x%02x%02x\" stroke-width=\"%d\" stroke-linejoin=\"round\"/>\n",
+		strings.Join(points, " "), routeColor.R, routeColor.G, routeColor.B, helpers.MaxOf(1, scale/3),
+	))
+	sb.WriteString("</svg>\n")
+
+	return sb.String()
+}
+
+// getImage returns the map as an image with the route drawn over it, each
+// position being a square of scale x scale pixels.
+func (m *Mapheight) getImage(scale int) *image.RGBA {
+	height := len(m.grid)
+	width := 0
+	if height > 0 {
+		width = len(m.grid[0])
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width*scale, height*scale))
+	fill := func(x0, y0, x1, y1 int, c color.RGBA) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+
+	for y, row := range m.grid {
+		for x, h := range row {
+			fill(x*scale, y*scale, (x+1)*scale, (y+1)*scale, getHeightColor(h))
+		}
+	}
+
+	// the route is a line through the centre of the positions it visits
+	thickness := helpers.MaxOf(1, scale/3)
+	offset := (scale - thickness) / 2
+	path := m.getRoutePath()
+	for i, point := range path {
+		x0, y0 := point.x*scale+offset, point.y*scale+offset
+		fill(x0, y0, x0+thickness, y0+thickness, routeColor)
+
+		if i == len(path)-1 {
+			continue
+		}
+
+		next := path[i+1]
+		x1, y1 := next.x*scale+offset, next.y*scale+offset
+		fill(helpers.MinOf(x0, x1), helpers.MinOf(y0, y1), helpers.MaxOf(x0, x1)+thickness, helpers.MaxOf(y0, y1)+thickness, routeColor)
+	}
+
+	return img
+}
+
+// saveFile writes the content produced by the writer function to a file.
+func saveFile(filename string, write func(file *os.File) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// renderRoute prints and exports the route as requested on the command line.
+func renderRoute(m *Mapheight) {
+	if *printArrows {
+		fmt.Println(m.renderArrows())
+	}
+
+	if *printColors {
+		fmt.Println(m.renderColors())
+	}
+
+	scale := helpers.MaxOf(1, *imageScale)
+
+	if *routeSVG != "" {
+		err := saveFile(*routeSVG, func(file *os.File) error {
+			_, err := file.WriteString(m.renderSVG(scale))
+			return err
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *routePNG != "" {
+		err := saveFile(*routePNG, func(file *os.File) error {
+			return png.Encode(file, m.getImage(scale))
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"os"
 	"reflect"
 	"sort"
 	"strings"
@@ -98,66 +101,118 @@ func comparePackets(left, right interface{}) (bool, error) {
 	return false, fmt.Errorf(ERR_UNDETERMINED)
 }
 
+// checkPacket checks that a packet is made only of lists and integers.
+func checkPacket(value interface{}) error {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return fmt.Errorf("%v is not an integer", v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := checkPacket(item); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%v is neither a list nor an integer", v)
+	}
+
+	return nil
+}
+
 // parsePacketFromLine parses a packet from a line.
-func parsePacketFromLine(line string) []interface{} {
-    var packet []interface{}
+func parsePacketFromLine(line string) ([]interface{}, error) {
+	var packet []interface{}
 
-    err := json.Unmarshal([]byte(line), &packet)
+	err := json.Unmarshal([]byte(line), &packet)
 
-    if err != nil {
-		fmt.Println(err)
-	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
 
-	return packet
-}
+	switch {
+	case errors.As(err, &syntaxErr):
+		return nil, helpers.NewParseError(0, int(syntaxErr.Offset), line, "invalid packet: %s", err)
+	case errors.As(err, &typeErr):
+		return nil, helpers.NewParseError(0, 1, line, "a packet must be a list")
+	case err != nil:
+		return nil, helpers.NewParseError(0, 0, line, "invalid packet: %s", err)
+	}
 
-// getPacketFromLines gets a packet from two lines.
-func getPacketFromLines(lines []string) ([]interface{}, []interface{}) {
-	left := parsePacketFromLine(lines[0])
-	right := parsePacketFromLine(lines[1])
+	if err := checkPacket(packet); err != nil {
+		return nil, helpers.NewParseError(0, 0, line, "invalid packet: %s", err)
+	}
 
-	return left, right
+	return packet, nil
 }
 
-// getPairsFromFile gets all pairs from a file.
-func getPairsFromFile(txtlines []string) []*Pair {
-	pairs := []*Pair{}
+// getPacketsFromFile gets all packets from a file.
+func getPacketsFromFile(txtlines []string) ([]interface{}, error) {
+	packets := []interface{}{}
 
-	var currentPair []string
-	for _, line := range txtlines {
+	for i, line := range txtlines {
 		if line == "" {
 			continue
 		}
 
-		if len(currentPair) == 2 {
-			left, right := getPacketFromLines(currentPair)
-			pairs = append(pairs, newPair(left, right))
-			currentPair = []string{}
+		packet, err := parsePacketFromLine(line)
+		if err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
 		}
 
-		currentPair = append(currentPair, line)
+		packets = append(packets, packet)
 	}
 
-	left, right := getPacketFromLines(currentPair)
-	pairs = append(pairs, newPair(left, right))
-
-	return pairs
+	return packets, nil
 }
 
-// getPacketsFromFile gets all packets from a file.
-func getPacketsFromFile(txtlines []string) []interface{} {
-	packets := []interface{}{}
+// getPairsFromFile gets all pairs from a file. Each pair is a group of two
+// packets, separated from the other pairs by blank lines.
+func getPairsFromFile(txtlines []string) ([]*Pair, error) {
+	pairs := []*Pair{}
+	var group [][]interface{}
+	groupLine := 0
+
+	// addPair adds the current group as a pair
+	addPair := func() error {
+		if len(group) == 0 {
+			return nil
+		}
+
+		if len(group) != 2 {
+			return helpers.NewParseError(groupLine, 0, txtlines[groupLine-1], "expected 2 packets in the pair, got %d", len(group))
+		}
 
-	for _, line := range txtlines {
+		pairs = append(pairs, newPair(group[0], group[1]))
+		group = nil
+
+		return nil
+	}
+
+	for i, line := range txtlines {
 		if line == "" {
+			if err := addPair(); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
-		packet := parsePacketFromLine(line)
-		packets = append(packets, packet)
+		packet, err := parsePacketFromLine(line)
+		if err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
+		}
+
+		if len(group) == 0 {
+			groupLine = i + 1
+		}
+		group = append(group, packet)
 	}
 
-	return packets
+	if err := addPair(); err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
 }
 
 // getIndicesInRightOrder gets the indices of the pairs in the right order.
@@ -215,7 +270,11 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	pairs := getPairsFromFile(txtlines)
+	pairs, err := getPairsFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the packets: %s\n", err)
+		os.Exit(1)
+	}
 
 	// part 1
 	indicesInRightOrder := getIndicesInRightOrder(pairs)
@@ -229,10 +288,10 @@ func main() {
 	extraLines := strings.Split(EXTRA_LINES, NEW_LINE)
 	fullTxtlines := append(txtlines, extraLines...)
 
-	// part 2
-	packets := getPacketsFromFile(fullTxtlines)
+	// part 2, whose lines were already checked with the pairs
+	packets, _ := getPacketsFromFile(fullTxtlines)
 	packetsInRightOrder := sortPairsByRightOrder(packets)
-	dividers := getPacketsFromFile(extraLines)
+	dividers, _ := getPacketsFromFile(extraLines)
 	dividerIndices := findDividerIndices(packetsInRightOrder, dividers)
 	decoderKey := helpers.MultiplyInts(dividerIndices...)
 	fmt.Printf(
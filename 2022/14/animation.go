@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const ANSI_CLEAR_SCREEN = "\x1b[H\x1b[2J"
+
+// newAnimation returns a frame function that redraws the cave in the terminal
+// after every grain of sand, or nil if the animation is disabled.
+func newAnimation() func(c *Cave) {
+	if !*animate {
+		return nil
+	}
+
+	return func(c *Cave) {
+		fmt.Print(ANSI_CLEAR_SCREEN)
+		fmt.Print(c)
+		fmt.Printf("Grains of sand at rest: %d\n", c.numFallenSand)
+		time.Sleep(*animationDelay)
+	}
+}
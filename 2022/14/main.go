@@ -1,19 +1,28 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"regexp"
-	"strconv"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
 const VERBOSE = false
-const INFINITY = int(^uint(0) >> 1)
 const POINT_DELIMITER = ","
+const POINT_PATTERN = "{x:int}" + POINT_DELIMITER + "{y:int}"
+const PATH_DELIMITER = " -> "
 const FLOOR_LEVEL = 2
-const FLOOR_INFINITY = 9999
+const DEFAULT_SAND_SOURCE = "500,0"
+const DEFAULT_FALL_RULES = "0,1 -1,1 1,1"
+
+var sandSources = flag.String("sources", DEFAULT_SAND_SOURCE, "space separated positions the sand pours from")
+var fallRules = flag.String("rules", DEFAULT_FALL_RULES, "space separated moves a grain of sand tries, in order")
+var simulateFloor = flag.Bool("simulate", false, "drop every grain in part two instead of counting the reachable positions")
+var animate = flag.Bool("animate", false, "play the grains of sand falling in the terminal")
+var animationDelay = flag.Duration("delay", 20*time.Millisecond, "time between frames of the animation")
 
 // Element is the type of the element in the cave.
 type Element int
@@ -32,323 +41,370 @@ func (e Element) String() string {
 
 // Point is a point in the cave.
 type Point struct {
-	x, y    int
-	element Element
+	x, y int
 }
 
-// shouldStop returns true if the point should stop the sand.
-func (p *Point) shouldStop() bool {
-	if p.element == Rock {
-		return true
-	}
+// add returns the point moved by the given offset.
+func (p Point) add(offset Point) Point {
+	return Point{p.x + offset.x, p.y + offset.y}
+}
 
-	if p.element == Sand {
-		return true
-	}
+// Cave is the cave. Only rock and sand are stored, everything else is air, so
+// the cave has no bounds.
+type Cave struct {
+	cells         map[Point]Element
+	sandSources   []Point
+	fallRules     []Point
+	yMax          int
+	hasFloor      bool
+	numFallenSand int
+	lastPath      []Point
+}
 
-	if p.element == SandSource {
-		return true
+// newCave creates a new cave from rock paths. With a floor, the cave has an
+// infinite floor FLOOR_LEVEL below the lowest rock, otherwise sand falling
+// below the lowest rock is lost in the abyss.
+func newCave(rockPaths [][]Point, sandSources []Point, fallRules []Point, hasFloor bool) *Cave {
+	c := &Cave{
+		cells:       make(map[Point]Element),
+		sandSources: sandSources,
+		fallRules:   fallRules,
+		hasFloor:    hasFloor,
 	}
 
-	return false
-}
-
-// String returns the string representation of the point.
-func (p *Point) String() string {
-	switch p.element {
-	case Rock:
-		return "#"
-	case Air:
-		return "."
-	case Sand:
-		return "o"
-	case SandSource:
-		return "+"
-	default:
-		return " "
+	for _, rockPath := range rockPaths {
+		c.addRockPath(rockPath)
 	}
-}
 
-// Cave is the cave.
-type Cave struct {
-	grid                   [][]*Point
-	xMin, xMax, yMin, yMax int
-	sandSource             *Point
-	numFallenSand          int
-}
+	// sand can pour from below the lowest rock, it just falls into the abyss
+	for _, source := range sandSources {
+		c.yMax = helpers.MaxOf(c.yMax, source.y)
+	}
 
-// isSandSource returns true if the point is a sand source.
-func (c *Cave) isSandSouce(point *Point) bool {
-	return point.element == SandSource
+	return c
 }
 
-// isAbyss returns true if the point is an abyss.
-func (c *Cave) isAbyss(x, y int) bool {
-	return y > c.yMax
+// getFloor returns the level of the floor, below which nothing can go.
+func (c *Cave) getFloor() int {
+	return c.yMax + FLOOR_LEVEL
 }
 
-// exists returns true if the point exists.
-func (c *Cave) exists(x, y int) bool {
-	if x < c.xMin || x > c.xMax || y < c.yMin || y > c.yMax {
-		return true
+// getElement returns the element at the given point.
+func (c *Cave) getElement(p Point) Element {
+	if c.hasFloor && p.y >= c.getFloor() {
+		return Rock
 	}
 
-	return false
-}
+	if element, ok := c.cells[p]; ok {
+		return element
+	}
 
-// getPoint returns the point at the given coordinates.
-func (c *Cave) getPoint(x, y int) *Point {
-	if c.exists(x, y) {
-		return nil
+	for _, source := range c.sandSources {
+		if source == p {
+			return SandSource
+		}
 	}
 
-	return c.grid[y-c.yMin][x-c.xMin]
+	return Air
 }
 
-// addPoint adds a point to the cave.
-func (c *Cave) addPoint(point *Point) {
-	x := point.x - c.xMin
-	y := point.y - c.yMin
-	c.grid[y][x] = point
+// isBlocked returns true if sand cannot move into the point.
+func (c *Cave) isBlocked(p Point) bool {
+	element, ok := c.cells[p]
+
+	return (ok && element != Air) || (c.hasFloor && p.y >= c.getFloor())
 }
 
-// addSandSource adds a sand source to the cave.
-func (c *Cave) addSandSource(point *Point) {
-	c.addPoint(point)
-	c.sandSource = point
+// isAbyss returns true if sand at the point falls forever.
+func (c *Cave) isAbyss(p Point) bool {
+	return !c.hasFloor && p.y > c.yMax
 }
 
-// addRock adds a rock to the cave.
-func (c *Cave) addRockPath(rockPath []*Point) {
+// addRockPath adds the rocks along a path of horizontal and vertical lines.
+func (c *Cave) addRockPath(rockPath []Point) {
 	for i := 0; i < len(rockPath)-1; i++ {
-		firstPoint := rockPath[i]
-		secondPoint := rockPath[i+1]
-
-		for x := firstPoint.x; x <= secondPoint.x; x++ {
-			c.addPoint(&Point{
-				x:       x,
-				y:       firstPoint.y,
-				element: Rock,
-			})
+		from, to := rockPath[i], rockPath[i+1]
+		step := Point{helpers.SignInt(to.x - from.x), helpers.SignInt(to.y - from.y)}
+
+		for p := from; ; p = p.add(step) {
+			c.cells[p] = Rock
+			c.yMax = helpers.MaxOf(c.yMax, p.y)
+
+			if p == to {
+				break
+			}
 		}
+	}
+
+	if len(rockPath) == 1 {
+		c.cells[rockPath[0]] = Rock
+		c.yMax = helpers.MaxOf(c.yMax, rockPath[0].y)
+	}
+}
+
+// dropSand drops a grain of sand from a source and returns where it comes to
+// rest, or false if it falls into the abyss or the source is blocked.
+func (c *Cave) dropSand(source Point) (Point, bool) {
+	c.lastPath = c.lastPath[:0]
 
-		for y := firstPoint.y; y <= secondPoint.y; y++ {
-			c.addPoint(&Point{
-				x:       secondPoint.x,
-				y:       y,
-				element: Rock,
-			})
+	if c.isBlocked(source) {
+		return source, false
+	}
+
+	grain := source
+	for {
+		c.lastPath = append(c.lastPath, grain)
+
+		if c.isAbyss(grain) {
+			return grain, false
 		}
 
-		for x := secondPoint.x; x <= firstPoint.x; x++ {
-			c.addPoint(&Point{
-				x:       x,
-				y:       secondPoint.y,
-				element: Rock,
-			})
+		moved := false
+		for _, rule := range c.fallRules {
+			next := grain.add(rule)
+
+			if !c.isBlocked(next) {
+				grain = next
+				moved = true
+				break
+			}
 		}
 
-		for y := secondPoint.y; y <= firstPoint.y; y++ {
-			c.addPoint(&Point{
-				x:       secondPoint.x,
-				y:       y,
-				element: Rock,
-			})
+		if !moved {
+			c.cells[grain] = Sand
+			c.numFallenSand++
+
+			return grain, true
 		}
 	}
 }
 
-// fillWithAir fills the cave with air.
-func (c *Cave) fillWithAir() {
-	for y := c.yMin; y <= c.yMax; y++ {
-		for x := c.xMin; x <= c.xMax; x++ {
-			c.addPoint(&Point{
-				x:       x,
-				y:       y,
-				element: Air,
-			})
+// fillWithSand pours sand from every source in turn until a grain falls into
+// the abyss or every source is blocked. The frame function, if any, is called
+// after every grain.
+func (c *Cave) fillWithSand(frame func(c *Cave)) {
+	active := make([]bool, len(c.sandSources))
+	for i := range active {
+		active[i] = true
+	}
+
+	for numActive := len(active); numActive > 0; {
+		for i, source := range c.sandSources {
+			if !active[i] {
+				continue
+			}
+
+			rest, ok := c.dropSand(source)
+			if frame != nil {
+				frame(c)
+			}
+
+			if !ok {
+				if c.isAbyss(rest) {
+					return
+				}
+
+				active[i] = false
+				numActive--
+				continue
+			}
+
+			if rest == source {
+				active[i] = false
+				numActive--
+			}
+
+			if VERBOSE {
+				fmt.Println(c.numFallenSand)
+				fmt.Println(c)
+			}
 		}
 	}
 }
 
-// shouldStopAtPoint returns true if the sand should stop at the given point.
-func (c *Cave) shouldStopAtPoint(x, y int) (bool, bool) {
-	point := c.getPoint(x, y)
-
-	if c.isAbyss(x, y) {
-		return true, false
+// countReachable counts the positions sand can reach from the sources, which
+// is where sand ends up when the floor stops it from falling forever. This is
+// a search over the reachable positions instead of dropping every grain.
+func (c *Cave) countReachable() int {
+	visited := map[Point]bool{}
+	var queue []Point
+
+	for _, source := range c.sandSources {
+		if !c.isBlocked(source) && !visited[source] {
+			visited[source] = true
+			queue = append(queue, source)
+		}
 	}
 
-	if point == nil || !point.shouldStop() {
-		return false, true
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		for _, rule := range c.fallRules {
+			q := p.add(rule)
+
+			if !c.isBlocked(q) && !visited[q] {
+				visited[q] = true
+				queue = append(queue, q)
+			}
+		}
 	}
 
-	return false, false
+	return len(visited)
 }
 
-// fillWithSandFromPoint fills the cave with sand from the given point.
-func (c *Cave) fillWithSandFromPoint(startPoint *Point) bool {
-	x := startPoint.x
-	for y := startPoint.y; y <= c.yMax; y++ {
-		// Move down
-		stop, fall := c.shouldStopAtPoint(x, y)
-		if stop {
-			return false
-		}
-		if fall {
-			continue
-		}
+// getBounds returns the smallest rectangle with every rock, grain of sand and
+// source in it.
+func (c *Cave) getBounds() (Point, Point) {
+	min := c.sandSources[0]
+	max := c.sandSources[0]
+	points := append([]Point{}, c.sandSources...)
 
-		// Move left and down
-		x -= 1
-		stop, fall = c.shouldStopAtPoint(x, y)
-		if stop {
-			return false
-		}
-		if fall {
-			continue
-		}
+	for p := range c.cells {
+		points = append(points, p)
+	}
 
-		// Move right and down
-		x += 2
-		stop, fall = c.shouldStopAtPoint(x, y)
-		if stop {
-			return false
-		}
-		if fall {
-			continue
-		}
+	for _, p := range points {
+		min = Point{helpers.MinOf(min.x, p.x), helpers.MinOf(min.y, p.y)}
+		max = Point{helpers.MaxOf(max.x, p.x), helpers.MaxOf(max.y, p.y)}
+	}
 
-		// Stop falling
-		currentPoint := c.getPoint(x-1, y-1)
-		c.numFallenSand++
-		if c.isSandSouce(currentPoint) {
-			currentPoint.element = Sand
-			return false
-		}
-		currentPoint.element = Sand
-		return true
+	if c.hasFloor {
+		max.y = c.getFloor()
 	}
 
-	return false
+	return min, max
 }
 
-// fillWithSand fills the cave with sand.
-func (c *Cave) fillWithSand() {
-	x := c.sandSource.x
-	y := c.sandSource.y + 1
-	startPoint := c.getPoint(x, y)
-	do := c.fillWithSandFromPoint(startPoint)
+// render draws the cave within the bounds, with the path of the last grain of
+// sand drawn as ~.
+func (c *Cave) render(min, max Point) string {
+	var sb strings.Builder
+	path := map[Point]bool{}
 
-	for do {
-		do = c.fillWithSandFromPoint(startPoint)
+	for _, p := range c.lastPath {
+		path[p] = true
+	}
 
-		if VERBOSE {
-			fmt.Println(c.numFallenSand)
-			fmt.Println(c)
+	for y := min.y; y <= max.y; y++ {
+		for x := min.x; x <= max.x; x++ {
+			p := Point{x, y}
+
+			switch c.getElement(p) {
+			case Rock:
+				sb.WriteString("#")
+			case Sand:
+				sb.WriteString("o")
+			case SandSource:
+				sb.WriteString("+")
+			default:
+				if path[p] {
+					sb.WriteString("~")
+				} else {
+					sb.WriteString(".")
+				}
+			}
 		}
+		sb.WriteString("\n")
 	}
+
+	return sb.String()
 }
 
 // String returns the string representation of the cave.
 func (c *Cave) String() string {
-	str := ""
-	for _, row := range c.grid {
-		for _, point := range row {
-			if point == nil {
-				str += " "
-				continue
-			}
-			str += point.String()
-		}
-		str += "\n"
-	}
-	return str
+	return c.render(c.getBounds())
 }
 
-// newCave creates a new cave.
-func newCave(xMin, xMax, yMin, yMax int) *Cave {
-	c := Cave{
-		xMin: xMin,
-		xMax: xMax,
-		yMin: yMin,
-		yMax: yMax,
+// parsePoint parses a point such as "498,4".
+func parsePoint(input string) (Point, error) {
+	var point struct{ X, Y int }
+	if err := helpers.MustCompilePattern(POINT_PATTERN).Unmarshal(strings.TrimSpace(input), &point); err != nil {
+		return Point{}, fmt.Errorf("invalid point %q, expected x%sy", input, POINT_DELIMITER)
 	}
 
-	c.grid = make([][]*Point, c.yMax-c.yMin+1)
+	return Point{point.X, point.Y}, nil
+}
+
+// parsePoints parses a space separated list of points, such as sand sources
+// or fall rules.
+func parsePoints(input string) ([]Point, error) {
+	var points []Point
 
-	for i := range c.grid {
-		c.grid[i] = make([]*Point, c.xMax-c.xMin+1)
+	for _, field := range helpers.SplitFields(input, " ") {
+		point, err := parsePoint(field.Text)
+		if err != nil {
+			return nil, field.Errorf("%s", err)
+		}
+
+		points = append(points, point)
 	}
 
-	c.fillWithAir()
+	if len(points) == 0 {
+		return nil, fmt.Errorf("expected at least one point in %q", input)
+	}
 
-	return &c
+	return points, nil
 }
 
-// getRockPath returns the rock path.
-func getRockPath(rockPathPoints [][]string) [][]*Point {
-	rockPath := make([][]*Point, len(rockPathPoints))
-
-	for i, points := range rockPathPoints {
-		rockPath[i] = make([]*Point, len(points))
-
-		for j, pointString := range points {
-			point := strings.Split(pointString, POINT_DELIMITER)
-			x, _ := strconv.Atoi(point[0])
-			y, _ := strconv.Atoi(point[1])
-			rockPath[i][j] = &Point{
-				x:       x,
-				y:       y,
-				element: Rock,
+// getRockPath returns the rock path, made of horizontal and vertical lines.
+func getRockPath(line string) ([]Point, error) {
+	var rockPath []Point
+
+	for _, field := range helpers.SplitFields(line, PATH_DELIMITER) {
+		point, err := parsePoint(field.Text)
+		if err != nil {
+			return nil, field.Errorf("%s", err)
+		}
+
+		if len(rockPath) > 0 {
+			previous := rockPath[len(rockPath)-1]
+			if previous.x != point.x && previous.y != point.y {
+				return nil, field.Errorf("rock path from %v to %v is neither horizontal nor vertical", previous, point)
 			}
 		}
+
+		rockPath = append(rockPath, point)
 	}
 
-	return rockPath
+	return rockPath, nil
 }
 
-// getMinMaxCoords returns the min and max coordinates of the rock path.
-func getMinMaxCoords(rockPathPoints [][]*Point) (int, int, int, int) {
-	xMin := INFINITY
-	xMax := 0
-	yMin := INFINITY
-	yMax := 0
-
-	for _, points := range rockPathPoints {
-		for _, point := range points {
-			if point.x < xMin {
-				xMin = point.x
-			}
-
-			if point.x > xMax {
-				xMax = point.x
-			}
+// getRockPathsFromFile returns the rock paths from the file.
+func getRockPathsFromFile(txtlines []string) ([][]Point, error) {
+	var rockPaths [][]Point
 
-			if point.y < yMin {
-				yMin = point.y
-			}
+	for i, line := range txtlines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
 
-			if point.y > yMax {
-				yMax = point.y
-			}
+		rockPath, err := getRockPath(line)
+		if err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
 		}
+
+		rockPaths = append(rockPaths, rockPath)
 	}
 
-	return xMin, xMax, yMin, yMax
+	return rockPaths, nil
 }
 
-// getPointsFromFile returns the points from the file.
-func getPointsFromFile(txtlines []string) [][]string {
-	points := make([][]string, len(txtlines))
+// getFallRules parses the moves a grain of sand tries. Every move must go down,
+// which guarantees grains eventually stop.
+func getFallRules(input string) ([]Point, error) {
+	rules, err := parsePoints(input)
+	if err != nil {
+		return nil, err
+	}
 
-	for i, line := range txtlines {
-		re := regexp.MustCompile(`(\d+),(\d+)`)
-		matches := re.FindAllString(line, -1)
-		points[i] = matches
+	for _, rule := range rules {
+		if rule.y < 1 {
+			return nil, fmt.Errorf("fall rule %d%s%d does not move down", rule.x, POINT_DELIMITER, rule.y)
+		}
 	}
 
-	return points
+	return rules, nil
 }
 
 // main is the entry point for the application.
@@ -358,58 +414,41 @@ func main() {
 	filename := args[0]
 	txtlines := helpers.ReadFile(filename)
 
-	// create the sand source
-	sandSource := &Point{
-		x:       500,
-		y:       0,
-		element: SandSource,
+	rockPaths, err := getRockPathsFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing rock paths: %s\n", err)
+		os.Exit(1)
 	}
 
-	// part 1
-	rockPathPoints := getPointsFromFile(txtlines)
-	rockPaths := getRockPath(rockPathPoints)
-	rockPathsWithSandSource := append(rockPaths, []*Point{sandSource})
-	xMin, xMax, yMin, yMax := getMinMaxCoords(rockPathsWithSandSource)
-	cave := newCave(xMin, xMax, yMin, yMax)
-	for _, rockPath := range rockPaths {
-		cave.addRockPath(rockPath)
+	sources, err := parsePoints(*sandSources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid sand sources: %s\n", err)
+		os.Exit(1)
+	}
+
+	rules, err := getFallRules(*fallRules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid fall rules: %s\n", err)
+		os.Exit(1)
 	}
-	cave.addSandSource(sandSource)
-	cave.fillWithSand()
+
+	// part 1
+	cave := newCave(rockPaths, sources, rules, false)
+	cave.fillWithSand(newAnimation())
 	fmt.Printf(
 		"[Part One] The answer is: %d\n",
 		cave.numFallenSand,
 	)
 
 	// part 2
-	rockPathPoints = getPointsFromFile(txtlines)
-	rockPaths = getRockPath(rockPathPoints)
-	rockPathsWithSandSource = append(rockPaths, []*Point{sandSource})
-	xMin, xMax, yMin, yMax = getMinMaxCoords(rockPathsWithSandSource)
-	xMin -= FLOOR_INFINITY
-	xMax += FLOOR_INFINITY
-	yMax += FLOOR_LEVEL
-	floor := []*Point{
-		&Point{
-			x:       xMin,
-			y:       yMax,
-			element: Rock,
-		},
-		&Point{
-			x:       xMax,
-			y:       yMax,
-			element: Rock,
-		},
+	cave = newCave(rockPaths, sources, rules, true)
+	numFallenSand := cave.countReachable()
+	if *simulateFloor || *animate {
+		cave.fillWithSand(newAnimation())
+		numFallenSand = cave.numFallenSand
 	}
-	cave = newCave(xMin, xMax, yMin, yMax)
-	for _, rockPath := range rockPaths {
-		cave.addRockPath(rockPath)
-	}
-	cave.addRockPath(floor)
-	cave.addSandSource(sandSource)
-	cave.fillWithSand()
 	fmt.Printf(
 		"[Part Two] The answer is: %d\n",
-		cave.numFallenSand,
+		numFallenSand,
 	)
 }
@@ -2,19 +2,16 @@ package main
 
 import (
 	"fmt"
-	"regexp"
+	"os"
 	"sort"
 	"strconv"
-	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
 
 const VERBOSE = true
 const INFINITY = int(^uint(0) >> 1)
-const POINT_DELIMITER = ", "
-const X_DELIMITER = "x="
-const Y_DELIMITER = "y="
+const SENSOR_PATTERN = "Sensor at x={sensorX:int}, y={sensorY:int}: closest beacon is at x={beaconX:int}, y={beaconY:int}"
 const COVERAGE_AT = 2000000
 const MIN_COORDINATE = 0
 const MAX_COORDINATE = 20
@@ -352,31 +349,24 @@ func newCave(xMin, xMax, yMin, yMax, offset int) *Cave {
 	return &c
 }
 
-// getSensorAndBeaconFromLine returns the sensor and beacon from the given line.
-func getSensorAndBeaconFromLine(line string) (*Point, *Point) {
-	sensor := &Point{}
-	beacon := &Point{}
-
-	re := regexp.MustCompile(`x=(-?\d+), y=(-?\d+)`)
-	matches := re.FindAllString(line, -1)
-
-	sensorXY := strings.Split(matches[0], POINT_DELIMITER)
-	sensorX, _ := strconv.Atoi(strings.Replace(sensorXY[0], X_DELIMITER, "", 1))
-	sensorY, _ := strconv.Atoi(strings.Replace(sensorXY[1], Y_DELIMITER, "", 1))
-
-	beaconXY := strings.Split(matches[1], POINT_DELIMITER)
-	beaconX, _ := strconv.Atoi(strings.Replace(beaconXY[0], X_DELIMITER, "", 1))
-	beaconY, _ := strconv.Atoi(strings.Replace(beaconXY[1], Y_DELIMITER, "", 1))
+// SensorLine is a sensor and its closest beacon as described in the input.
+type SensorLine struct {
+	SensorX, SensorY int
+	BeaconX, BeaconY int
+}
 
-	sensor.x = sensorX
-	sensor.y = sensorY
-	sensor.element = Sensor
+// getSensorAndBeaconFromLine returns the sensor and beacon from the line, such
+// as "Sensor at x=2, y=18: closest beacon is at x=-2, y=15".
+func getSensorAndBeaconFromLine(line string) (*Point, *Point, error) {
+	var sensorLine SensorLine
+	if err := helpers.MustCompilePattern(SENSOR_PATTERN).Unmarshal(line, &sensorLine); err != nil {
+		return nil, nil, err
+	}
 
-	beacon.x = beaconX
-	beacon.y = beaconY
-	beacon.element = Beacon
+	sensor := &Point{x: sensorLine.SensorX, y: sensorLine.SensorY, element: Sensor}
+	beacon := &Point{x: sensorLine.BeaconX, y: sensorLine.BeaconY, element: Beacon}
 
-	return sensor, beacon
+	return sensor, beacon, nil
 }
 
 // getMinMaxCoords returns the min and max x and y coordinates.
@@ -408,16 +398,21 @@ func getMinMaxCoords(points []*Point) (int, int, int, int) {
 }
 
 // getPointsFromFile returns the points from the given lines.
-func getPointsFromFile(lines []string) []*Point {
+func getPointsFromFile(lines []string) ([]*Point, error) {
 	points := []*Point{}
 
-	for _, line := range lines {
-		sensor, beacon := getSensorAndBeaconFromLine(line)
+	cursor := helpers.NewLineCursor(lines)
+	for cursor.NextNonBlank() {
+		sensor, beacon, err := getSensorAndBeaconFromLine(cursor.Text())
+		if err != nil {
+			return nil, cursor.Wrap(err)
+		}
+
 		sensor.closest = beacon
 		points = append(points, sensor, beacon)
 	}
 
-	return points
+	return points, nil
 }
 
 // main is the entry point for the application.
@@ -430,10 +425,18 @@ func main() {
 	// part 1
 	coverageAt := COVERAGE_AT
 	if len(args) > 1 {
-		coverageAt, _ = strconv.Atoi(args[1])
+		var err error
+		if coverageAt, err = strconv.Atoi(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid row %q\n", args[1])
+			os.Exit(1)
+		}
 	}
 	caveOffset := coverageAt * 2
-	points := getPointsFromFile(txtlines)
+	points, err := getPointsFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the sensors: %s\n", err)
+		os.Exit(1)
+	}
 	xMin, xMax, yMin, yMax := getMinMaxCoords(points)
 	cave := newCave(
 		xMin,
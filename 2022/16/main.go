@@ -2,9 +2,9 @@ package main
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
+	"os"
 	"strings"
+	"unicode"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
@@ -172,32 +172,84 @@ func getPressureReleased(positions []*Position) int {
 	return pressureReleased
 }
 
-func getPositionsFromFile(txtlines []string) []*Position {
-	valveMap := make(map[string]*Valve, len(txtlines))
-	positions := make([]*Position, len(txtlines))
+// VALVE_PATTERNS are the formats of a valve, leading to many valves or one.
+var VALVE_PATTERNS = []string{
+	"Valve {label} has flow rate={flowRate:int}; tunnels lead to valves {next:[]string}",
+	"Valve {label} has flow rate={flowRate:int}; tunnel leads to valve {next:[]string}",
+}
+
+// ValveLine is a valve as described in the input, with the columns of its
+// labels.
+type ValveLine struct {
+	Label    helpers.Field
+	FlowRate int
+	Next     []helpers.Field
+}
+
+// parseValve parses a valve such as "Valve BB has flow rate=13; tunnels lead
+// to valves CC, AA".
+func parseValve(line string) (*ValveLine, error) {
+	var valve ValveLine
+	if err := helpers.UnmarshalLine(line, &valve, VALVE_PATTERNS...); err != nil {
+		return nil, err
+	}
+
+	for _, label := range append([]helpers.Field{valve.Label}, valve.Next...) {
+		for _, r := range label.Text {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return nil, label.Errorf("invalid valve %q", label.Text)
+			}
+		}
+	}
+
+	if len(valve.Next) == 0 {
+		return nil, helpers.NewParseError(0, 0, line, "valve %s leads to no valves", valve.Label.Text)
+	}
+
+	return &valve, nil
+}
 
-	for i, line := range txtlines {
-		re1 := regexp.MustCompile(`(\d+)`)
-		matches1 := re1.FindAllString(line, -1)
-		flowRate, _ := strconv.Atoi(matches1[0])
+func getPositionsFromFile(txtlines []string) ([]*Position, error) {
+	valveMap := make(map[string]*Valve, len(txtlines))
+	defined := make(map[string]bool, len(txtlines))
+	positions := []*Position{}
+
+	// the first tunnel to each valve and its line, in order
+	var tunnels []helpers.Field
+	tunnelLines := make(map[string]int, len(txtlines))
+
+	cursor := helpers.NewLineCursor(txtlines)
+	for cursor.NextNonBlank() {
+		valveLine, err := parseValve(cursor.Text())
+		if err != nil {
+			return nil, cursor.Wrap(err)
+		}
 
-		re2 := regexp.MustCompile(`([A-Z]{2})`)
-		matches2 := re2.FindAllString(line, -1)
-		valveLabel := matches2[0]
-		positionNext := matches2[1:]
+		valveLabel := valveLine.Label.Text
+		if defined[valveLabel] {
+			return nil, cursor.ErrorfAt(1, "valve %s is defined more than once", valveLabel)
+		}
+		defined[valveLabel] = true
 
 		if valve, ok := valveMap[valveLabel]; ok {
-			valve.flowRate = flowRate
+			valve.flowRate = valveLine.FlowRate
 		} else {
-			valveMap[valveLabel] = newValve(valveLabel, flowRate)
+			valveMap[valveLabel] = newValve(valveLabel, valveLine.FlowRate)
 		}
 
 		position := &Position{
 			value:   valveMap[valveLabel],
-			leadsTo: make([]*Valve, len(positionNext)),
+			leadsTo: make([]*Valve, len(valveLine.Next)),
 		}
 
-		for j, nextValveLabel := range positionNext {
+		for j, next := range valveLine.Next {
+			nextValveLabel := next.Text
+
+			if _, ok := tunnelLines[nextValveLabel]; !ok {
+				tunnelLines[nextValveLabel] = cursor.Line()
+				tunnels = append(tunnels, next)
+			}
+
 			if valve, ok := valveMap[nextValveLabel]; ok {
 				position.leadsTo[j] = valve
 			} else {
@@ -206,10 +258,22 @@ func getPositionsFromFile(txtlines []string) []*Position {
 			}
 		}
 
-		positions[i] = position
+		positions = append(positions, position)
 	}
 
-	return positions
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("the input has no valves")
+	}
+
+	// every valve a tunnel leads to must be defined
+	for _, tunnel := range tunnels {
+		if !defined[tunnel.Text] {
+			line := tunnelLines[tunnel.Text]
+			return nil, helpers.AtLine(tunnel.Errorf("valve %s is never defined", tunnel.Text), line, txtlines[line-1])
+		}
+	}
+
+	return positions, nil
 }
 
 // main is the entry point for the application.
@@ -220,7 +284,11 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	positions := getPositionsFromFile(txtlines)
+	positions, err := getPositionsFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the valves: %s\n", err)
+		os.Exit(1)
+	}
 	for _, position := range positions {
 		fmt.Println(position)
 	}
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"math"
+	"os"
 	"strconv"
 	"strings"
 
@@ -13,280 +14,243 @@ const VERBOSE = false
 const COORDINATES_DELIMITER = ","
 const FACES_PER_CUBE = 6
 
+var printPockets = flag.Bool("pockets", false, "print the air pockets trapped inside the droplet")
+var objFile = flag.String("obj", "", "export the droplet surface as a Wavefront OBJ file")
+var stlFile = flag.String("stl", "", "export the droplet surface as an STL file")
+var exteriorOnly = flag.Bool("exterior", false, "only export the faces on the exterior of the droplet")
+
 // Point represents a point in 3D space.
 type Point struct {
 	x, y, z int
 }
 
 // String returns a string representation of the point.
-func (p *Point) String() string {
+func (p Point) String() string {
 	return fmt.Sprintf("(%d, %d, %d)", p.x, p.y, p.z)
 }
 
-// Cube represents a cube.
-type Cube struct {
-	position *Point
+// add returns the point moved by the given offset.
+func (p Point) add(offset Point) Point {
+	return Point{p.x + offset.x, p.y + offset.y, p.z + offset.z}
 }
 
-// String returns a string representation of the cube.
-func (c *Cube) String() string {
-	return c.position.String()
+// FACE_DIRECTIONS are the directions the faces of a cube point to.
+var FACE_DIRECTIONS = [FACES_PER_CUBE]Point{
+	{1, 0, 0},
+	{-1, 0, 0},
+	{0, 1, 0},
+	{0, -1, 0},
+	{0, 0, 1},
+	{0, 0, -1},
 }
 
 // Grid represents a grid of cubes.
 type Grid struct {
-	cubes      []*Cube
-	cubesMap   map[int]map[int]map[int]bool
-	minX, maxX int
-	minY, maxY int
-	minZ, maxZ int
+	cubes    []Point
+	voxels   map[Point]bool
+	min, max Point
 }
 
-// getAdjacentFaces returns the number of adjacent faces.
-func (g *Grid) getAdjacentFaces() int {
-	adjacentFaces := 0
-	for _, cube := range g.cubes {
-		for _, otherCube := range g.cubes {
-			cubesFaces := 0
-
-			if cube == otherCube {
-				continue
-			}
-
-			if cube.position.x == otherCube.position.x && cube.position.y == otherCube.position.y {
-				if cube.position.z == otherCube.position.z+1 || cube.position.z == otherCube.position.z-1 {
-					cubesFaces++
-				}
-			}
-
-			if cube.position.x == otherCube.position.x && cube.position.z == otherCube.position.z {
-				if cube.position.y == otherCube.position.y+1 || cube.position.y == otherCube.position.y-1 {
-					cubesFaces++
-				}
-			}
-
-			if cube.position.y == otherCube.position.y && cube.position.z == otherCube.position.z {
-				if cube.position.x == otherCube.position.x+1 || cube.position.x == otherCube.position.x-1 {
-					cubesFaces++
-				}
-			}
-
-			adjacentFaces += cubesFaces
-		}
+// newGrid returns a new grid.
+func newGrid() *Grid {
+	return &Grid{
+		voxels: make(map[Point]bool),
 	}
-
-	return adjacentFaces
 }
 
-// getMinMax returns the min and max values for the grid.
-func (g *Grid) getMinMax() {
-	minX, maxX := math.MaxInt32, math.MinInt32
-	minY, maxY := math.MaxInt32, math.MinInt32
-	minZ, maxZ := math.MaxInt32, math.MinInt32
-
-	for _, cube := range g.cubes {
-		if cube.position.x < minX {
-			minX = cube.position.x
-		}
-		if cube.position.x > maxX {
-			maxX = cube.position.x
-		}
-		if cube.position.y < minY {
-			minY = cube.position.y
-		}
-		if cube.position.y > maxY {
-			maxY = cube.position.y
-		}
-		if cube.position.z < minZ {
-			minZ = cube.position.z
-		}
-		if cube.position.z > maxZ {
-			maxZ = cube.position.z
-		}
+// addCube adds a cube to the grid, extending its bounds.
+func (g *Grid) addCube(p Point) error {
+	if g.voxels[p] {
+		return fmt.Errorf("duplicate cube at %s", p)
 	}
 
-	g.minX = minX
-	g.maxX = maxX
-	g.minY = minY
-	g.maxY = maxY
-	g.minZ = minZ
-	g.maxZ = maxZ
-}
+	if len(g.cubes) == 0 {
+		g.min, g.max = p, p
+	}
 
-func (g *Grid) withinBounds(p *Point) bool {
-	return p.x >= g.minX && p.x <= g.maxX && p.y >= g.minY && p.y <= g.maxY && p.z >= g.minZ && p.z <= g.maxZ
-}
+	g.min = Point{helpers.MinOf(g.min.x, p.x), helpers.MinOf(g.min.y, p.y), helpers.MinOf(g.min.z, p.z)}
+	g.max = Point{helpers.MaxOf(g.max.x, p.x), helpers.MaxOf(g.max.y, p.y), helpers.MaxOf(g.max.z, p.z)}
 
-func (g *Grid) exists(p *Point) bool {
-	for _, cube := range g.cubes {
-		if cube.position.x == p.x && cube.position.y == p.y && cube.position.z == p.z {
-			return true
-		}
-	}
+	g.cubes = append(g.cubes, p)
+	g.voxels[p] = true
 
-	return false
+	return nil
 }
 
-func (g *Grid) addToInternalGrid(p *Point, v bool) {
-	if _, ok := g.cubesMap[p.x]; !ok {
-		g.cubesMap[p.x] = make(map[int]map[int]bool)
-	}
-	if _, ok := g.cubesMap[p.x][p.y]; !ok {
-		g.cubesMap[p.x][p.y] = make(map[int]bool)
-	}
-	g.cubesMap[p.x][p.y][p.z] = v
+// withinBounds checks if the point is inside the bounding box of the grid
+// grown by a margin on every side.
+func (g *Grid) withinBounds(p Point, margin int) bool {
+	return p.x >= g.min.x-margin && p.x <= g.max.x+margin &&
+		p.y >= g.min.y-margin && p.y <= g.max.y+margin &&
+		p.z >= g.min.z-margin && p.z <= g.max.z+margin
 }
 
-func (g *Grid) inInternalGrid(p *Point) bool {
-	if _, ok := g.cubesMap[p.x]; !ok {
-		return false
-	}
-	if _, ok := g.cubesMap[p.x][p.y]; !ok {
-		return false
-	}
-	if _, ok := g.cubesMap[p.x][p.y][p.z]; !ok {
-		return false
-	}
-
-	return true
+// exists checks if there's a cube at the point.
+func (g *Grid) exists(p Point) bool {
+	return g.voxels[p]
 }
 
-// getInternalFaces returns the number of internal faces.
-func (g *Grid) getInternalCubes() []*Point {
-	queue := []*Point{}
-	start := &Point{g.minX, g.minY, g.minZ}
-	queue = append(queue, start)
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		g.addToInternalGrid(current, true)
-
-		x, y, z := current.x, current.y, current.z
-		directions := []*Point{
-			&Point{x, y, z + 1},
-			&Point{x, y, z - 1},
-			&Point{x, y + 1, z},
-			&Point{x, y - 1, z},
-			&Point{x + 1, y, z},
-			&Point{x - 1, y, z},
-		}
+// floodFill returns the air connected to the start without going through
+// cubes or leaving the bounding box grown by the margin.
+func (g *Grid) floodFill(start Point, margin int, visited map[Point]bool) []Point {
+	region := []Point{start}
+	visited[start] = true
 
-		for _, direction := range directions {
-			if alreadyInQueue(queue, direction) {
-				continue
-			}
+	for i := 0; i < len(region); i++ {
+		for _, direction := range FACE_DIRECTIONS {
+			next := region[i].add(direction)
 
-			if g.inInternalGrid(direction) {
+			if visited[next] || g.exists(next) || !g.withinBounds(next, margin) {
 				continue
 			}
 
-			if !g.withinBounds(direction) {
-				continue
-			}
+			visited[next] = true
+			region = append(region, next)
+		}
+	}
 
-			if g.exists(direction) {
-				continue
-			}
+	return region
+}
 
-			queue = append(queue, direction)
-		}
+// getExteriorAir returns the air outside the droplet. The flood fill starts
+// from a corner of the bounding box padded by one, so it can wrap around the
+// whole droplet.
+func (g *Grid) getExteriorAir() map[Point]bool {
+	exterior := make(map[Point]bool)
+
+	if len(g.cubes) > 0 {
+		g.floodFill(g.min.add(Point{-1, -1, -1}), 1, exterior)
 	}
 
-	internalCubes := []*Point{}
-	for x := g.minX; x <= g.maxX; x++ {
-		for y := g.minY; y <= g.maxY; y++ {
-			for z := g.minZ; z <= g.maxZ; z++ {
-				current := &Point{x, y, z}
+	return exterior
+}
 
-				if g.inInternalGrid(current) {
-					continue
-				}
+// getAirPockets returns the connected components of the air trapped inside
+// the droplet.
+func (g *Grid) getAirPockets() [][]Point {
+	visited := g.getExteriorAir()
+	pockets := [][]Point{}
+
+	for x := g.min.x; x <= g.max.x; x++ {
+		for y := g.min.y; y <= g.max.y; y++ {
+			for z := g.min.z; z <= g.max.z; z++ {
+				current := Point{x, y, z}
 
-				if g.exists(current) {
+				if visited[current] || g.exists(current) {
 					continue
 				}
 
-				internalCubes = append(internalCubes, current)
+				pockets = append(pockets, g.floodFill(current, 0, visited))
 			}
 		}
 	}
 
-	return internalCubes
+	return pockets
 }
 
-// getExternalSurfaceArea returns the external surface area of the grid.
-func (g *Grid) getInternalSurfaceArea() int {
-	internalCubes := g.getInternalCubes()
-	newGrid := newGrid(len(internalCubes))
+// Face is the face of a cube pointing in one of the FACE_DIRECTIONS.
+type Face struct {
+	cube      Point
+	direction int
+}
 
-	for i, cube := range internalCubes {
-		newGrid.cubes[i] = &Cube{position: cube}
+// getExposedFaces returns the faces not touching another cube, or only those
+// touching the air outside the droplet.
+func (g *Grid) getExposedFaces(exteriorOnly bool) []Face {
+	var exterior map[Point]bool
+	if exteriorOnly {
+		exterior = g.getExteriorAir()
 	}
 
-	surfaceArea := newGrid.getSurfaceArea()
+	faces := []Face{}
+	for _, cube := range g.cubes {
+		for i, direction := range FACE_DIRECTIONS {
+			neighbour := cube.add(direction)
+
+			if g.exists(neighbour) || (exteriorOnly && !exterior[neighbour]) {
+				continue
+			}
 
-	return surfaceArea
+			faces = append(faces, Face{cube, i})
+		}
+	}
+
+	return faces
 }
 
 // getSurfaceArea returns the surface area of the grid.
 func (g *Grid) getSurfaceArea() int {
-	totalFaces := len(g.cubes) * FACES_PER_CUBE
-	adjacentFaces := g.getAdjacentFaces()
-	return totalFaces - adjacentFaces
+	return len(g.getExposedFaces(false))
+}
+
+// getExteriorSurfaceArea returns the surface area on the outside of the grid.
+func (g *Grid) getExteriorSurfaceArea() int {
+	return len(g.getExposedFaces(true))
 }
 
 // String returns a string representation of the grid.
 func (g *Grid) String() string {
-	str := ""
+	var sb strings.Builder
 	for _, cube := range g.cubes {
-		str += cube.String() + "\n"
+		sb.WriteString(cube.String() + "\n")
 	}
-	return str
+	return sb.String()
 }
 
-// newGrid returns a new grid.
-func newGrid(n int) *Grid {
-	g := &Grid{
-		cubes: make([]*Cube, n),
-		cubesMap: make(map[int]map[int]map[int]bool),
+// getCoordinatesFromLine returns the coordinates from a line.
+func getCoordinatesFromLine(line string) (Point, error) {
+	coordinates := strings.Split(strings.TrimSpace(line), COORDINATES_DELIMITER)
+	if len(coordinates) != 3 {
+		return Point{}, fmt.Errorf("expected x%sy%sz, got %q", COORDINATES_DELIMITER, COORDINATES_DELIMITER, line)
 	}
-	return g
-}
 
-func alreadyInQueue(queue []*Point, direction *Point) bool {
-	for _, p := range queue {
-		if p.x == direction.x && p.y == direction.y && p.z == direction.z {
-			return true
+	values := [3]int{}
+	for i, coordinate := range coordinates {
+		value, err := strconv.Atoi(strings.TrimSpace(coordinate))
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid coordinate %q", coordinate)
 		}
+		values[i] = value
 	}
-	return false
-}
 
-// getCoordinatesFromLine returns the coordinates from a line.
-func getCoordinatesFromLine(line string) (int, int, int) {
-	coordinates := strings.Split(line, COORDINATES_DELIMITER)
-	x, _ := strconv.Atoi(coordinates[0])
-	y, _ := strconv.Atoi(coordinates[1])
-	z, _ := strconv.Atoi(coordinates[2])
-	return x, y, z
+	return Point{values[0], values[1], values[2]}, nil
 }
 
 // getGridFromFile returns a grid from a file.
-func getGridFromFile(txtlines []string) *Grid {
-	grid := newGrid(len(txtlines))
+func getGridFromFile(txtlines []string) (*Grid, error) {
+	grid := newGrid()
+
 	for i, line := range txtlines {
-		x, y, z := getCoordinatesFromLine(line)
-		grid.cubes[i] = &Cube{
-			position: &Point{
-				x: x,
-				y: y,
-				z: z,
-			},
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		p, err := getCoordinatesFromLine(line)
+		if err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
+		}
+
+		if err := grid.addCube(p); err != nil {
+			return nil, helpers.AtLine(err, i+1, line)
+		}
+	}
+
+	return grid, nil
+}
+
+// printAirPockets prints the size and cubes of every air pocket.
+func printAirPockets(pockets [][]Point) {
+	fmt.Printf("%d air pocket(s)\n", len(pockets))
+
+	for i, pocket := range pockets {
+		fmt.Printf("Pocket %d: %d cube(s)\n", i+1, len(pocket))
+
+		if VERBOSE {
+			for _, p := range pocket {
+				fmt.Printf("  %s\n", p)
+			}
 		}
 	}
-	return grid
 }
 
 // main is the entry point for the application.
@@ -297,7 +261,11 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	grid := getGridFromFile(txtlines)
+	grid, err := getGridFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing cubes: %s\n", err)
+		os.Exit(1)
+	}
 
 	// part 1
 	surfaceArea := grid.getSurfaceArea()
@@ -307,7 +275,12 @@ func main() {
 	)
 
 	// part 2
-	grid.getMinMax()
-	internalSurfaceArea := grid.getInternalSurfaceArea()
-	fmt.Println(surfaceArea, "-", internalSurfaceArea, "=", surfaceArea - internalSurfaceArea)
+	exteriorSurfaceArea := grid.getExteriorSurfaceArea()
+	fmt.Println(surfaceArea, "-", surfaceArea-exteriorSurfaceArea, "=", exteriorSurfaceArea)
+
+	if *printPockets {
+		printAirPockets(grid.getAirPockets())
+	}
+
+	exportMeshes(grid)
 }
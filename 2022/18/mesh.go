@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+const MESH_NAME = "droplet"
+
+// FACE_CORNERS are the corners of each face of a unit cube, in the same order
+// as FACE_DIRECTIONS, wound counter-clockwise when seen from outside.
+var FACE_CORNERS = [FACES_PER_CUBE][4]Point{
+	{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {1, 0, 1}},
+	{{0, 0, 0}, {0, 0, 1}, {0, 1, 1}, {0, 1, 0}},
+	{{0, 1, 0}, {0, 1, 1}, {1, 1, 1}, {1, 1, 0}},
+	{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {0, 0, 1}},
+	{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}},
+	{{0, 0, 0}, {0, 1, 0}, {1, 1, 0}, {1, 0, 0}},
+}
+
+// getCorners returns the corners of the face in space.
+func (f Face) getCorners() [4]Point {
+	corners := FACE_CORNERS[f.direction]
+
+	for i, corner := range corners {
+		corners[i] = f.cube.add(corner)
+	}
+
+	return corners
+}
+
+// renderOBJ returns the faces as a Wavefront OBJ mesh of quads, sharing the
+// vertices between faces.
+func renderOBJ(faces []Face) string {
+	var vertices, polygons strings.Builder
+	indices := make(map[Point]int)
+
+	vertices.WriteString(fmt.Sprintf("o %s\n", MESH_NAME))
+
+	for _, face := range faces {
+		polygons.WriteString("f")
+
+		for _, corner := range face.getCorners() {
+			index, ok := indices[corner]
+			if !ok {
+				index = len(indices) + 1
+				indices[corner] = index
+				vertices.WriteString(fmt.Sprintf("v %d %d %d\n", corner.x, corner.y, corner.z))
+			}
+
+			polygons.WriteString(fmt.Sprintf(" %d", index))
+		}
+
+		polygons.WriteString("\n")
+	}
+
+	return vertices.String() + polygons.String()
+}
+
+// renderSTL returns the faces as an ASCII STL mesh, two triangles per face.
+func renderSTL(faces []Face) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("solid %s\n", MESH_NAME))
+
+	for _, face := range faces {
+		normal := FACE_DIRECTIONS[face.direction]
+		corners := face.getCorners()
+
+		for _, triangle := range [2][3]Point{
+			{corners[0], corners[1], corners[2]},
+			{corners[0], corners[2], corners[3]},
+		} {
+			sb.WriteString(fmt.Sprintf("  facet normal %d %d %d\n", normal.x, normal.y, normal.z))
+			sb.WriteString("    outer loop\n")
+			for _, vertex := range triangle {
+				sb.WriteString(fmt.Sprintf("      vertex %d %d %d\n", vertex.x, vertex.y, vertex.z))
+			}
+			sb.WriteString("    endloop\n")
+			sb.WriteString("  endfacet\n")
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("endsolid %s\n", MESH_NAME))
+
+	return sb.String()
+}
+
+// exportMeshes writes the droplet surface to the files requested on the
+// command line.
+func exportMeshes(g *Grid) {
+	if *objFile == "" && *stlFile == "" {
+		return
+	}
+
+	faces := g.getExposedFaces(*exteriorOnly)
+
+	if *objFile != "" {
+		if err := os.WriteFile(*objFile, []byte(renderOBJ(faces)), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *stlFile != "" {
+		if err := os.WriteFile(*stlFile, []byte(renderSTL(faces)), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
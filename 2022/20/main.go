@@ -1,8 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"math"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
 )
@@ -17,8 +21,16 @@ const DECRYPTION_KEY_2 = 811589153
 const MOVE_TIMES_1 = 1
 const MOVE_TIMES_2 = 10
 
+var decryptionKey = flag.Int("key", DECRYPTION_KEY_2, "decryption key for part two")
+var moveTimes = flag.Int("rounds", MOVE_TIMES_2, "number of times the message is mixed in part two")
+var verify = flag.Bool("verify", false, "check the mix against the naive slice version")
+
+// Number is a number of the message. Its shift is how far it moves when
+// mixed, which is its value modulo the length of the message minus one.
 type Number struct {
 	value int
+	shift int
+	block *block
 }
 
 func (n Number) String() string {
@@ -33,19 +45,16 @@ func newNumber(value int) *Number {
 type Message struct {
 	encrypted []*Number
 	decrypted []*Number
-}
-
-// getNextIndex returns the next index of the number in the decrypted message.
-func (m Message) getNextIndex(n *Number, currentIndex int) int {
-	numElements := len(m.encrypted)
-	lastIndex := numElements - 1
-
-	return helpers.EuclideanRemainder(currentIndex + n.value, lastIndex)
+	list      *MixList
 }
 
 // getGroveCoordinate returns the grove coordinate of the decrypted message.
-func (m Message) getGroveCoordinate(n int) *Number {
+func (m *Message) getGroveCoordinate(n int) (*Number, error) {
 	zeroIndex := findIndexByValue(m.decrypted, 0)
+	if zeroIndex < 0 {
+		return nil, fmt.Errorf("the message has no 0")
+	}
+
 	decryptedIndex := (zeroIndex + n) % len(m.decrypted)
 
 	if VERBOSE {
@@ -53,101 +62,110 @@ func (m Message) getGroveCoordinate(n int) *Number {
 		fmt.Println("--> decryptedIndex:", decryptedIndex)
 	}
 
-	return m.decrypted[decryptedIndex]
+	return m.decrypted[decryptedIndex], nil
 }
 
 // getSumOfCoordinates returns the sum of the grove coordinates.
-func (m Message) getSumOfCoordinates(indices []int) int {
+func (m *Message) getSumOfCoordinates(indices []int) (int, error) {
 	sumCoordinates := 0
 
 	for _, groveCoordinateIndex := range indices {
-		groveCoordinate := m.getGroveCoordinate(groveCoordinateIndex)
-		sumCoordinates += groveCoordinate.value
+		groveCoordinate, err := m.getGroveCoordinate(groveCoordinateIndex)
+		if err != nil {
+			return 0, err
+		}
+
+		sum := sumCoordinates + groveCoordinate.value
+		if (groveCoordinate.value > 0 && sum < sumCoordinates) || (groveCoordinate.value < 0 && sum > sumCoordinates) {
+			return 0, fmt.Errorf("the sum of the grove coordinates overflows")
+		}
+		sumCoordinates = sum
 
 		if VERBOSE {
 			fmt.Println("Grove coordinate", groveCoordinateIndex, "is", groveCoordinate)
 		}
 	}
 
-	return sumCoordinates
-}
-
-// getRealIndex returns the real index of the number in the encrypted message.
-func (m Message) getRealIndex(n int) int {
-	return n % len(m.encrypted)
-}
-
-// getRealValue returns the real value of the number in the encrypted message.
-func (m Message) getRealValue(n int) *Number {
-	return m.encrypted[m.getRealIndex(n)]
-}
-
-// moveIndex moves the index of the number in the decrypted message.
-func (m Message) moveIndex(n int) {
-	number := m.getRealValue(n)
-
-	indexFrom := findIndex(m.decrypted, number)
-	indexTo := m.getNextIndex(number, indexFrom)
-
-	if VERBOSE {
-		fmt.Println("--> number:", number)
-		fmt.Println("--> indexFrom:", indexFrom)
-		fmt.Println("--> indexTo:", indexTo)
-	}
-
-	move(m.decrypted, indexFrom, indexTo)
+	return sumCoordinates, nil
 }
 
 // moveAllIndexes moves all indexes of the numbers in the decrypted message.
-func (m Message) moveAllIndexes(times int) {
+func (m *Message) moveAllIndexes(times int) {
 	for j := 0; j < times; j++ {
-		for i := 0; i < len(m.encrypted); i++ {
-			m.moveIndex(i)
+		for i, number := range m.encrypted {
+			m.list.move(number)
 
 			if VERY_VERBOSE {
-				fmt.Println("------------------------------------- Moving index:", i, "(#",  j, ")")
+				fmt.Println("------------------------------------- Moving index:", i, "(#", j, ")")
+				m.decrypted = m.list.toSlice()
 				fmt.Println(m)
 			}
 		}
 	}
+
+	m.decrypted = m.list.toSlice()
 }
 
 // String returns the string representation of the message.
-func (m Message) String() string {
-	str := "Encrypted:\n"
+func (m *Message) String() string {
+	var sb strings.Builder
 
+	sb.WriteString("Encrypted:\n")
 	for _, number := range m.encrypted {
-		str += number.String() + ", "
+		sb.WriteString(number.String() + ", ")
 	}
 
-	str += "\n\nDecrypted:\n"
-
+	sb.WriteString("\n\nDecrypted:\n")
 	for _, number := range m.decrypted {
-		str += number.String() + ", "
+		sb.WriteString(number.String() + ", ")
 	}
 
-	return str
+	return sb.String()
 }
 
-// newMessage creates a new Message.
-func newMessage(encrypted []*Number, key int) Message {
-	encryptedWithKey := multiplyAllByNumber(encrypted, key)
-	decryptedWithKey := make([]*Number, len(encryptedWithKey))
-	copy(decryptedWithKey, encryptedWithKey)
-	m := Message{
-		encrypted: encryptedWithKey,
-		decrypted: decryptedWithKey,
+// newMessage creates a new Message, applying the decryption key to the
+// numbers. The shifts are computed modulo the length of the message minus one
+// before multiplying, so they stay exact whatever the key is.
+func newMessage(values []int, key int) (*Message, error) {
+	encrypted := make([]*Number, len(values))
+	modulo := len(values) - 1
+
+	for i, value := range values {
+		decrypted, ok := multiply(value, key)
+		if !ok {
+			return nil, fmt.Errorf("%d multiplied by the decryption key %d overflows", value, key)
+		}
+
+		encrypted[i] = newNumber(decrypted)
+		if modulo > 0 {
+			encrypted[i].shift = multiplyModulo(value, key, modulo)
+		}
+	}
+
+	m := &Message{
+		encrypted: encrypted,
+		decrypted: append([]*Number{}, encrypted...),
+		list:      newMixList(encrypted),
 	}
-	return m
+
+	return m, nil
 }
 
-// multiplyAllByNumber multiplies all numbers in the array by a number.
-func multiplyAllByNumber(numbers []*Number, n int) []*Number {
-	for i, number := range numbers {
-		numbers[i] = newNumber(number.value * n)
+// multiply multiplies two numbers, reporting false if the result overflows.
+func multiply(a, b int) (int, bool) {
+	result := a * b
+
+	if a != 0 && (result/a != b || (a == -1 && b == math.MinInt)) {
+		return result, false
 	}
 
-	return numbers
+	return result, true
+}
+
+// multiplyModulo returns a * b modulo m, without overflowing for any a and b
+// as long as m * m fits in an int.
+func multiplyModulo(a, b, m int) int {
+	return helpers.EuclideanRemainder(helpers.EuclideanRemainder(a, m)*helpers.EuclideanRemainder(b, m), m)
 }
 
 // findIndexByValue returns the index of the number in the array.
@@ -161,44 +179,43 @@ func findIndexByValue(array []*Number, n int) int {
 	return -1
 }
 
-// findIndex returns the index of the number in the array.
-func findIndex(array []*Number, n *Number) int {
-	for i, number := range array {
-		if number == n {
-			return i
+// getEncryptedMessageFromFile returns the encrypted message from the file.
+func getEncryptedMessageFromFile(txtlines []string) ([]int, error) {
+	encrypted := []int{}
+
+	for i, line := range txtlines {
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
-	}
 
-	return -1
-}
+		number, err := strconv.Atoi(strings.TrimSpace(line))
+		if err != nil {
+			return nil, helpers.NewParseError(i+1, 0, line, "invalid number %q", line)
+		}
 
-// insert inserts a number in the array.
-func insert(array []*Number, value *Number, index int) []*Number {
-    return append(array[:index], append([]*Number{value}, array[index:]...)...)
-}
+		encrypted = append(encrypted, number)
+	}
 
-// remove removes a number from the array.
-func remove(array []*Number, index int) []*Number {
-    return append(array[:index], array[index+1:]...)
+	return encrypted, nil
 }
 
-// move moves a number in the array.
-func move(array []*Number, srcIndex int, dstIndex int) []*Number {
-    value := array[srcIndex]
-    return insert(remove(array, srcIndex), value, dstIndex)
-}
+// decrypt mixes the message and returns the sum of the grove coordinates,
+// checking the mix against the naive one if asked to.
+func decrypt(values []int, key int, times int, indices []int) (int, error) {
+	message, err := newMessage(values, key)
+	if err != nil {
+		return 0, err
+	}
 
-// getEncryptedMessageFromFile returns the encrypted message from the file.
-func getEncryptedMessageFromFile(txtlines []string) []*Number {
-	encrypted := []*Number{}
+	message.moveAllIndexes(times)
 
-	for _, line := range txtlines {
-		numberInt, _ := strconv.Atoi(line)
-		number := newNumber(numberInt)
-		encrypted = append(encrypted, number)
+	if *verify {
+		if err := verifyMix(message, values, key, times); err != nil {
+			return 0, err
+		}
 	}
 
-	return encrypted
+	return message.getSumOfCoordinates(indices)
 }
 
 // main is the entry point for the application.
@@ -209,7 +226,11 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// process the file
-	encrypted := getEncryptedMessageFromFile(txtlines)
+	encrypted, err := getEncryptedMessageFromFile(txtlines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing the message: %s\n", err)
+		os.Exit(1)
+	}
 
 	// constants
 	groveCoordinateIndices := []int{
@@ -219,18 +240,22 @@ func main() {
 	}
 
 	// part 1
-	message1 := newMessage(encrypted, DECRYPTION_KEY_1)
-	message1.moveAllIndexes(MOVE_TIMES_1)
-	sumCoordinates1 := message1.getSumOfCoordinates(groveCoordinateIndices)
+	sumCoordinates1, err := decrypt(encrypted, DECRYPTION_KEY_1, MOVE_TIMES_1, groveCoordinateIndices)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed decrypting the message: %s\n", err)
+		os.Exit(1)
+	}
 	fmt.Printf(
 		"[Part One] The answer is: %d\n",
 		sumCoordinates1,
 	)
 
 	// part 2
-	message2 := newMessage(encrypted, DECRYPTION_KEY_2)
-	message2.moveAllIndexes(MOVE_TIMES_2)
-	sumCoordinates2 := message2.getSumOfCoordinates(groveCoordinateIndices)
+	sumCoordinates2, err := decrypt(encrypted, *decryptionKey, *moveTimes, groveCoordinateIndices)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed decrypting the message: %s\n", err)
+		os.Exit(1)
+	}
 	fmt.Printf(
 		"[Part Two] The answer is: %d\n",
 		sumCoordinates2,
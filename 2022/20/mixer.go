@@ -0,0 +1,131 @@
+package main
+
+import (
+	"math"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// block is a run of consecutive numbers of the mixing list.
+type block struct {
+	numbers []*Number
+}
+
+// MixList is a circular list split in blocks of about √n numbers, so finding
+// a number's position and moving it by any amount both take O(√n).
+type MixList struct {
+	blocks    []*block
+	size      int
+	blockSize int
+	moves     int
+}
+
+// newMixList creates a new MixList with the numbers in order.
+func newMixList(numbers []*Number) *MixList {
+	l := &MixList{
+		size:      len(numbers),
+		blockSize: int(math.Sqrt(float64(len(numbers)))) + 1,
+	}
+	l.rebuild(numbers)
+
+	return l
+}
+
+// rebuild splits the numbers into blocks of equal size again.
+func (l *MixList) rebuild(numbers []*Number) {
+	l.blocks = nil
+
+	for start := 0; start < len(numbers); start += l.blockSize {
+		end := start + l.blockSize
+		if end > len(numbers) {
+			end = len(numbers)
+		}
+
+		b := &block{numbers: append([]*Number{}, numbers[start:end]...)}
+		for _, n := range b.numbers {
+			n.block = b
+		}
+
+		l.blocks = append(l.blocks, b)
+	}
+}
+
+// indexOf returns the position of the number in the list.
+func (l *MixList) indexOf(n *Number) int {
+	index := 0
+
+	for _, b := range l.blocks {
+		if b != n.block {
+			index += len(b.numbers)
+			continue
+		}
+
+		for i, other := range b.numbers {
+			if other == n {
+				return index + i
+			}
+		}
+	}
+
+	return -1
+}
+
+// remove removes the number from its block.
+func (l *MixList) remove(n *Number) {
+	b := n.block
+
+	for i, other := range b.numbers {
+		if other == n {
+			b.numbers = append(b.numbers[:i], b.numbers[i+1:]...)
+			break
+		}
+	}
+
+	n.block = nil
+}
+
+// insert inserts the number at the position.
+func (l *MixList) insert(n *Number, index int) {
+	for _, b := range l.blocks {
+		if index <= len(b.numbers) {
+			b.numbers = append(b.numbers[:index], append([]*Number{n}, b.numbers[index:]...)...)
+			n.block = b
+			return
+		}
+
+		index -= len(b.numbers)
+	}
+}
+
+// move moves the number by its shift, wrapping around the list. A number that
+// goes around the list passes by every other number, so the shift is modulo
+// size - 1.
+func (l *MixList) move(n *Number) {
+	if l.size < 2 {
+		return
+	}
+
+	indexFrom := l.indexOf(n)
+	indexTo := helpers.EuclideanRemainder(indexFrom+n.shift, l.size-1)
+
+	l.remove(n)
+	l.insert(n, indexTo)
+
+	// blocks drift apart in size as numbers move, so they are evened out
+	// every √n moves to keep operations O(√n)
+	l.moves++
+	if l.moves%l.blockSize == 0 {
+		l.rebuild(l.toSlice())
+	}
+}
+
+// toSlice returns the numbers in order.
+func (l *MixList) toSlice() []*Number {
+	numbers := make([]*Number, 0, l.size)
+
+	for _, b := range l.blocks {
+		numbers = append(numbers, b.numbers...)
+	}
+
+	return numbers
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joaocarmo/advent-of-code/helpers"
+)
+
+// findIndex returns the index of the number in the array.
+func findIndex(array []*Number, n *Number) int {
+	for i, number := range array {
+		if number == n {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// insert inserts a number in the array.
+func insert(array []*Number, value *Number, index int) []*Number {
+	return append(array[:index], append([]*Number{value}, array[index:]...)...)
+}
+
+// remove removes a number from the array.
+func remove(array []*Number, index int) []*Number {
+	return append(array[:index], array[index+1:]...)
+}
+
+// move moves a number in the array.
+func move(array []*Number, srcIndex int, dstIndex int) []*Number {
+	value := array[srcIndex]
+	return insert(remove(array, srcIndex), value, dstIndex)
+}
+
+// mixNaive mixes the message by scanning and rebuilding a slice for every
+// move, which is O(n²) per mix but simple enough to check the MixList with.
+func mixNaive(values []int, key int, times int) []*Number {
+	encrypted := make([]*Number, len(values))
+	for i, value := range values {
+		encrypted[i] = newNumber(value * key)
+	}
+
+	decrypted := append([]*Number{}, encrypted...)
+	if len(decrypted) < 2 {
+		return decrypted
+	}
+
+	for j := 0; j < times; j++ {
+		for _, number := range encrypted {
+			indexFrom := findIndex(decrypted, number)
+			indexTo := helpers.EuclideanRemainder(indexFrom+number.value, len(decrypted)-1)
+			decrypted = move(decrypted, indexFrom, indexTo)
+		}
+	}
+
+	return decrypted
+}
+
+// verifyMix checks that the mixed message matches the naive mix, comparing
+// both from their 0 since the message is circular.
+func verifyMix(m *Message, values []int, key int, times int) error {
+	expected := mixNaive(values, key, times)
+	expectedZero := findIndexByValue(expected, 0)
+	zero := findIndexByValue(m.decrypted, 0)
+
+	if expectedZero < 0 || zero < 0 {
+		return fmt.Errorf("verification needs a 0 in the message")
+	}
+
+	for i := range expected {
+		want := expected[(expectedZero+i)%len(expected)].value
+		got := m.decrypted[(zero+i)%len(m.decrypted)].value
+
+		if want != got {
+			return fmt.Errorf("verification failed: %d numbers after 0, expected %d but got %d", i, want, got)
+		}
+	}
+
+	return nil
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"strconv"
+	"os"
 	"strings"
 
 	"github.com/joaocarmo/advent-of-code/helpers"
@@ -169,35 +169,70 @@ func (ml MonkeyList) setMonkey(monkey *Monkey) {
 	ml[monkey.name] = monkey
 }
 
-// parseOperation parses an operation.
-func (ml MonkeyList) parseOperation(operation string) *Operation {
-	var operator Operator
-	var leftSide, rightSide *Monkey
+// parseOperation parses an operation such as "pppw + sjmn" at the cursor.
+func (ml MonkeyList) parseOperation(cursor *helpers.LineCursor) (*Operation, error) {
+	start := cursor.Col()
+	left, err := cursor.Word()
+	cursor.SkipSpaces()
 
-	operationParts := strings.Split(operation, " ")
-	leftSide = ml.getMonkey(operationParts[0])
-	operator = newOperator(operationParts[1])
-	rightSide = ml.getMonkey(operationParts[2])
+	// a lone word is neither a number nor an operation
+	if err != nil || cursor.Rest() == "" {
+		return nil, cursor.ErrorfAt(start, "expected a number or an operation")
+	}
+
+	col := cursor.Col()
+	operatorString := cursor.Until(" ")
+	operator := newOperator(operatorString)
+	if operator < 0 || operator == Matches {
+		return nil, cursor.ErrorfAt(col, "invalid operator %q", operatorString)
+	}
+
+	cursor.SkipSpaces()
+	right, err := cursor.Word()
+	if err != nil {
+		return nil, err
+	}
 
-	return newOperation(operator, leftSide, rightSide)
+	return newOperation(operator, ml.getMonkey(left), ml.getMonkey(right)), nil
 }
 
-// getMonkeyFromLine returns a monkey from a line.
-func (ml MonkeyList) getMonkeyFromLine(line string, fixLogic bool) {
+// getMonkeyFromLine parses the monkey in the line at the cursor, such as
+// "root: pppw + sjmn" or "dbpl: 5", and returns its name.
+func (ml MonkeyList) getMonkeyFromLine(cursor *helpers.LineCursor, fixLogic bool) (string, error) {
 	job := YellNumber
 	var operation *Operation
-	monkeyAndJob := strings.Split(line, ":")
-	name := strings.Trim(monkeyAndJob[0], " ")
-	jobString := strings.Trim(monkeyAndJob[1], " ")
+	var number int
 
-	number, err := strconv.Atoi(jobString)
+	name, err := cursor.Word()
 	if err != nil {
+		return "", err
+	}
+
+	if err := cursor.Expect(":"); err != nil {
+		return "", err
+	}
+
+	cursor.SkipSpaces()
+	if rest := cursor.Rest(); rest != "" && strings.ContainsRune("+-0123456789", rune(rest[0])) {
+		number, err = cursor.Int()
+	} else {
 		job = YellOperation
-		operation = ml.parseOperation(jobString)
+		operation, err = ml.parseOperation(cursor)
+	}
+
+	if err == nil {
+		err = cursor.ExpectEnd()
+	}
+
+	if err != nil {
+		return "", err
 	}
 
 	if fixLogic {
 		if name == ROOT {
+			if operation == nil {
+				return "", cursor.ErrorfAt(1, "%s must yell an operation", ROOT)
+			}
 			operation.operator = Matches
 		} else if name == HUMAN {
 			number = 0
@@ -205,6 +240,8 @@ func (ml MonkeyList) getMonkeyFromLine(line string, fixLogic bool) {
 	}
 
 	ml.setMonkey(newMonkey(name, job, number, operation))
+
+	return name, nil
 }
 
 // getResultForMonkey solves for the result of a monkey.
@@ -245,15 +282,88 @@ func (ml MonkeyList) String() string {
 	return result
 }
 
+// findCycle returns a monkey whose operation depends on its own result,
+// directly or through other monkeys, looking from the monkeys in order. It
+// returns an empty name when there is none.
+func (ml MonkeyList) findCycle(order []string) string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	state := make(map[string]int, len(ml))
+
+	var visit func(monkey *Monkey) string
+	visit = func(monkey *Monkey) string {
+		switch state[monkey.name] {
+		case inProgress:
+			return monkey.name
+		case done:
+			return ""
+		}
+
+		state[monkey.name] = inProgress
+
+		if monkey.operation != nil {
+			for _, side := range []*Monkey{monkey.operation.leftSide, monkey.operation.rightSide} {
+				if name := visit(side); name != "" {
+					return name
+				}
+			}
+		}
+
+		state[monkey.name] = done
+
+		return ""
+	}
+
+	for _, name := range order {
+		if cycle := visit(ml[name]); cycle != "" {
+			return cycle
+		}
+	}
+
+	return ""
+}
+
 // getMonkeysFromFile returns a list of monkeys from a file.
-func getMonkeysFromFile(txtlines []string, fixLogic bool) *MonkeyList {
+func getMonkeysFromFile(txtlines []string, fixLogic bool) (*MonkeyList, error) {
 	monkeys := make(MonkeyList, len(txtlines))
+	defined := make(map[string]int, len(txtlines))
+	var order []string
+
+	cursor := helpers.NewLineCursor(txtlines)
+	for cursor.NextNonBlank() {
+		name, err := monkeys.getMonkeyFromLine(cursor, fixLogic)
+		if err != nil {
+			return nil, err
+		}
+
+		if line, ok := defined[name]; ok {
+			return nil, cursor.ErrorfAt(1, "monkey %s is already defined on line %d", name, line)
+		}
+		defined[name] = cursor.Line()
+		order = append(order, name)
+	}
+
+	// every monkey that is listened to must yell something
+	for name := range monkeys {
+		if _, ok := defined[name]; !ok {
+			return nil, fmt.Errorf("monkey %s is never defined", name)
+		}
+	}
 
-	for _, line := range txtlines {
-		monkeys.getMonkeyFromLine(line, fixLogic)
+	if _, ok := defined[ROOT]; !ok {
+		return nil, fmt.Errorf("monkey %s is never defined", ROOT)
 	}
 
-	return &monkeys
+	// no monkey can wait for its own result
+	if name := monkeys.findCycle(order); name != "" {
+		return nil, helpers.NewParseError(defined[name], 0, txtlines[defined[name]-1], "monkey %s depends on its own result", name)
+	}
+
+	return &monkeys, nil
 }
 
 // main is the entry point for the application.
@@ -264,7 +374,11 @@ func main() {
 	txtlines := helpers.ReadFile(filename)
 
 	// part 1
-	monkeys1 := getMonkeysFromFile(txtlines, false)
+	monkeys1, err := getMonkeysFromFile(txtlines, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the monkeys: %s\n", err)
+		os.Exit(1)
+	}
 	monkeys1.getResultForMonkey(ROOT)
 	root1 := monkeys1.getMonkey(ROOT)
 	fmt.Printf(
@@ -273,7 +387,11 @@ func main() {
 	)
 
 	// part 2
-	monkeys2 := getMonkeysFromFile(txtlines, true)
+	monkeys2, err := getMonkeysFromFile(txtlines, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed reading the monkeys: %s\n", err)
+		os.Exit(1)
+	}
 	monkeys2.solve()
 	if !VERBOSE {
 		fmt.Println(monkeys2)
@@ -1,25 +1,18 @@
 package helpers
 
-import (
-	"strconv"
-	"strings"
-)
-
 // GetInitialState returns the initial state of the fish
-func GetInitialState(txtlines []string) []int {
+func GetInitialState(txtlines []string) ([]int, error) {
 	var initialState []int
 
-	for _, line := range txtlines {
+	for i, line := range txtlines {
 		// convert the line to an int array
-		if line != "" {
-			states := strings.Split(line, ",")
-
-			for _, state := range states {
-				stateInt, _ := strconv.Atoi(state)
-				initialState = append(initialState, stateInt)
-			}
+		states, err := StringToIntArray(line, ",")
+		if err != nil {
+			return nil, AtLine(err, i+1, line)
 		}
+
+		initialState = append(initialState, states...)
 	}
 
-	return initialState
+	return initialState, nil
 }
@@ -1,3 +1,3 @@
 module github.com/joaocarmo/advent-of-code/helpers
 
-go 1.17
+go 1.18
@@ -15,6 +15,18 @@ func AbsInt(x int) int {
 	return AbsDiffInt(x, 0)
 }
 
+// SignInt returns -1, 0 or 1 depending on the sign of the given integer.
+func SignInt(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+
+	return 0
+}
+
 // MinOf returns the minimum of the given values.
 func MinOf(vars ...int) int {
 	min := vars[0]
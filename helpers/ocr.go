@@ -0,0 +1,192 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OCR_UNKNOWN is the character used for glyphs that are not recognized.
+const OCR_UNKNOWN = '?'
+
+// ocrFontSmall is the 6 pixels tall font, with letters 4 pixels wide except
+// for I and Y.
+var ocrFontSmall = map[rune]string{
+	'A': ".##.\n#..#\n#..#\n####\n#..#\n#..#",
+	'B': "###.\n#..#\n###.\n#..#\n#..#\n###.",
+	'C': ".##.\n#..#\n#...\n#...\n#..#\n.##.",
+	'E': "####\n#...\n###.\n#...\n#...\n####",
+	'F': "####\n#...\n###.\n#...\n#...\n#...",
+	'G': ".##.\n#..#\n#...\n#.##\n#..#\n.###",
+	'H': "#..#\n#..#\n####\n#..#\n#..#\n#..#",
+	'I': "###\n.#.\n.#.\n.#.\n.#.\n###",
+	'J': "..##\n...#\n...#\n...#\n#..#\n.##.",
+	'K': "#..#\n#.#.\n##..\n#.#.\n#.#.\n#..#",
+	'L': "#...\n#...\n#...\n#...\n#...\n####",
+	'O': ".##.\n#..#\n#..#\n#..#\n#..#\n.##.",
+	'P': "###.\n#..#\n#..#\n###.\n#...\n#...",
+	'R': "###.\n#..#\n#..#\n###.\n#.#.\n#..#",
+	'S': ".###\n#...\n#...\n.##.\n...#\n###.",
+	'U': "#..#\n#..#\n#..#\n#..#\n#..#\n.##.",
+	'Y': "#...#\n#...#\n.#.#.\n..#..\n..#..\n..#..",
+	'Z': "####\n...#\n..#.\n.#..\n#...\n####",
+}
+
+// ocrFontLarge is the 10 pixels tall font, with letters 6 pixels wide.
+var ocrFontLarge = map[rune]string{
+	'A': "..##..\n.#..#.\n#....#\n#....#\n#....#\n######\n#....#\n#....#\n#....#\n#....#",
+	'B': "#####.\n#....#\n#....#\n#....#\n#####.\n#....#\n#....#\n#....#\n#....#\n#####.",
+	'C': ".####.\n#....#\n#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n#....#\n.####.",
+	'E': "######\n#.....\n#.....\n#.....\n#####.\n#.....\n#.....\n#.....\n#.....\n######",
+	'F': "######\n#.....\n#.....\n#.....\n#####.\n#.....\n#.....\n#.....\n#.....\n#.....",
+	'G': ".####.\n#....#\n#.....\n#.....\n#.....\n#..###\n#....#\n#....#\n#...##\n.###.#",
+	'H': "#....#\n#....#\n#....#\n#....#\n######\n#....#\n#....#\n#....#\n#....#\n#....#",
+	'J': "...###\n....#.\n....#.\n....#.\n....#.\n....#.\n....#.\n#...#.\n#...#.\n.###..",
+	'K': "#....#\n#...#.\n#..#..\n#.#...\n##....\n##....\n#.#...\n#..#..\n#...#.\n#....#",
+	'L': "#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n######",
+	'N': "#....#\n##...#\n##...#\n#.#..#\n#.#..#\n#..#.#\n#..#.#\n#...##\n#...##\n#....#",
+	'P': "#####.\n#....#\n#....#\n#....#\n#####.\n#.....\n#.....\n#.....\n#.....\n#.....",
+	'R': "#####.\n#....#\n#....#\n#....#\n#####.\n#..#..\n#...#.\n#...#.\n#....#\n#....#",
+	'X': "#....#\n#....#\n.#..#.\n.#..#.\n..##..\n..##..\n.#..#.\n.#..#.\n#....#\n#....#",
+	'Z': "######\n.....#\n.....#\n....#.\n...#..\n..#...\n.#....\n#.....\n#.....\n######",
+}
+
+// ocrGlyphs maps the height of a font to its glyphs, indexed by their pixels.
+var ocrGlyphs = map[int]map[string]rune{
+	6:  invertFont(ocrFontSmall),
+	10: invertFont(ocrFontLarge),
+}
+
+// invertFont indexes the letters of a font by their pixels.
+func invertFont(font map[rune]string) map[string]rune {
+	glyphs := make(map[string]rune, len(font))
+
+	for letter, pixels := range font {
+		glyphs[pixels] = letter
+	}
+
+	return glyphs
+}
+
+// isLitPixel checks whether a character represents a lit pixel.
+func isLitPixel(c rune) bool {
+	return c == '#' || c == '█'
+}
+
+// RecognizeLetters reads the block letters drawn in the lines, where "#" or
+// "█" are lit pixels and any other character is dark.
+func RecognizeLetters(lines []string) (string, error) {
+	pixels := [][]bool{}
+
+	for _, line := range lines {
+		row := []bool{}
+
+		for _, c := range line {
+			row = append(row, isLitPixel(c))
+		}
+
+		pixels = append(pixels, row)
+	}
+
+	return RecognizePixels(pixels)
+}
+
+// RecognizePixels reads the block letters drawn in a grid of pixels using
+// the 6 or the 10 pixels tall font, depending on the height of the text. The
+// letters must be separated by at least one dark column. Unrecognized letters
+// are replaced by OCR_UNKNOWN and reported in the error.
+func RecognizePixels(pixels [][]bool) (string, error) {
+	// drop the dark rows above and below the text
+	top, bottom := 0, len(pixels)
+	for top < bottom && !anyLit(pixels[top]) {
+		top++
+	}
+	for bottom > top && !anyLit(pixels[bottom-1]) {
+		bottom--
+	}
+
+	rows := pixels[top:bottom]
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	glyphs, ok := ocrGlyphs[len(rows)]
+	if !ok {
+		return "", fmt.Errorf("no font is %d pixels tall", len(rows))
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = MaxOf(width, len(row))
+	}
+
+	isLit := func(x, y int) bool {
+		return x < len(rows[y]) && rows[y][x]
+	}
+
+	isDarkColumn := func(x int) bool {
+		for y := range rows {
+			if isLit(x, y) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	var text strings.Builder
+	var unknown []int
+
+	for x := 0; x < width; x++ {
+		if isDarkColumn(x) {
+			continue
+		}
+
+		// a letter spans all the columns until the next dark one
+		start := x
+		for x < width && !isDarkColumn(x) {
+			x++
+		}
+
+		glyph := make([]string, len(rows))
+		for y := range rows {
+			var row strings.Builder
+
+			for i := start; i < x; i++ {
+				if isLit(i, y) {
+					row.WriteByte('#')
+				} else {
+					row.WriteByte('.')
+				}
+			}
+
+			glyph[y] = row.String()
+		}
+
+		letter, ok := glyphs[strings.Join(glyph, "\n")]
+		if !ok {
+			letter = OCR_UNKNOWN
+			unknown = append(unknown, start)
+		}
+
+		text.WriteRune(letter)
+	}
+
+	if len(unknown) > 0 {
+		return text.String(), fmt.Errorf(
+			"unrecognized letter(s) starting at column(s) %s", IntArrayToString(unknown, ", "),
+		)
+	}
+
+	return text.String(), nil
+}
+
+// anyLit checks whether any pixel in the row is lit.
+func anyLit(row []bool) bool {
+	for _, pixel := range row {
+		if pixel {
+			return true
+		}
+	}
+
+	return false
+}
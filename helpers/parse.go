@@ -0,0 +1,319 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseError is an error in the input, at a line and column both counted from
+// 1. The line is 0 when it isn't known yet and the column is 0 when the error
+// is about the whole line. Text is the offending line, shown under the error.
+type ParseError struct {
+	Line int
+	Col  int
+	Msg  string
+	Text string
+}
+
+// Error returns the position and message of the error, followed by the
+// offending line and a caret under the column.
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+
+	switch {
+	case e.Line > 0 && e.Col > 0:
+		fmt.Fprintf(&sb, "line %d, column %d: ", e.Line, e.Col)
+	case e.Line > 0:
+		fmt.Fprintf(&sb, "line %d: ", e.Line)
+	case e.Col > 0:
+		fmt.Fprintf(&sb, "column %d: ", e.Col)
+	}
+
+	sb.WriteString(e.Msg)
+
+	if e.Text != "" {
+		fmt.Fprintf(&sb, "\n\t%s", e.Text)
+
+		if e.Col > 0 && e.Col <= len(e.Text)+1 {
+			fmt.Fprintf(&sb, "\n\t%s^", strings.Repeat(" ", len([]rune(e.Text[:e.Col-1]))))
+		}
+	}
+
+	return sb.String()
+}
+
+// NewParseError returns an error at a line and column of the input.
+func NewParseError(line int, col int, text string, format string, args ...interface{}) *ParseError {
+	return &ParseError{line, col, fmt.Sprintf(format, args...), text}
+}
+
+// AtLine places an error at a line of the input. A parse error keeps its
+// column, and any other error becomes the message of one.
+func AtLine(err error, line int, text string) error {
+	return AtLineOffset(err, line, text, 0)
+}
+
+// AtLineOffset places an error found in the part of a line starting at the
+// offset, in bytes, so the column of a parse error is moved by the offset.
+func AtLineOffset(err error, line int, text string, offset int) error {
+	if err == nil {
+		return nil
+	}
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		placed := *parseErr
+		placed.Line = line
+		placed.Text = text
+
+		if placed.Col > 0 {
+			placed.Col += offset
+		}
+
+		return &placed
+	}
+
+	return &ParseError{Line: line, Msg: err.Error(), Text: text}
+}
+
+// Field is a piece of a line and the column it starts at.
+type Field struct {
+	Text string
+	Col  int
+	line string
+}
+
+// SplitFields splits the text by the separator. A blank separator splits
+// around any run of white space, skipping the empty fields. Any other separator
+// keeps them, so a missing value can be reported at its column, and only a
+// blank text has no fields.
+func SplitFields(text string, separator string) []Field {
+	var fields []Field
+
+	if strings.TrimSpace(separator) == "" {
+		start := -1
+
+		for i, r := range text + " " {
+			switch {
+			case unicode.IsSpace(r) && start >= 0:
+				fields = append(fields, Field{text[start:i], start + 1, text})
+				start = -1
+			case !unicode.IsSpace(r) && start < 0:
+				start = i
+			}
+		}
+
+		return fields
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return fields
+	}
+
+	offset := 0
+	for _, part := range strings.Split(text, separator) {
+		indent := len(part) - len(strings.TrimLeftFunc(part, unicode.IsSpace))
+		fields = append(fields, Field{strings.TrimSpace(part), offset + indent + 1, text})
+
+		offset += len(part) + len(separator)
+	}
+
+	return fields
+}
+
+// Errorf returns an error at the column of the field.
+func (f Field) Errorf(format string, args ...interface{}) *ParseError {
+	return NewParseError(0, f.Col, f.line, format, args...)
+}
+
+// Int returns the field as an integer.
+func (f Field) Int() (int, error) {
+	if f.Text == "" {
+		return 0, f.Errorf("missing number")
+	}
+
+	value, err := strconv.Atoi(f.Text)
+	if err != nil {
+		return 0, f.Errorf("invalid number %q", f.Text)
+	}
+
+	return value, nil
+}
+
+// LineCursor walks through the lines of an input, and through the current
+// line as a sequence of typed fields, so errors can point at where the input
+// went wrong.
+type LineCursor struct {
+	lines []string
+	line  int
+	col   int
+}
+
+// NewLineCursor returns a cursor before the first line.
+func NewLineCursor(lines []string) *LineCursor {
+	return &LineCursor{lines: lines, line: -1}
+}
+
+// Next moves to the start of the next line, if there is one.
+func (c *LineCursor) Next() bool {
+	if c.line >= len(c.lines) {
+		return false
+	}
+
+	c.line++
+	c.col = 0
+
+	return c.line < len(c.lines)
+}
+
+// NextNonBlank moves to the start of the next line that isn't blank, if there
+// is one.
+func (c *LineCursor) NextNonBlank() bool {
+	for c.Next() {
+		if strings.TrimSpace(c.Text()) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Line returns the number of the current line, counting from 1.
+func (c *LineCursor) Line() int {
+	return c.line + 1
+}
+
+// Col returns the current column, counting from 1.
+func (c *LineCursor) Col() int {
+	return c.col + 1
+}
+
+// Text returns the current line.
+func (c *LineCursor) Text() string {
+	if c.line < 0 || c.line >= len(c.lines) {
+		return ""
+	}
+
+	return c.lines[c.line]
+}
+
+// Rest returns what is left of the current line.
+func (c *LineCursor) Rest() string {
+	return c.Text()[c.col:]
+}
+
+// Errorf returns an error at the current column of the current line.
+func (c *LineCursor) Errorf(format string, args ...interface{}) *ParseError {
+	return c.ErrorfAt(c.Col(), format, args...)
+}
+
+// ErrorfAt returns an error at a column of the current line.
+func (c *LineCursor) ErrorfAt(col int, format string, args ...interface{}) *ParseError {
+	return NewParseError(c.Line(), col, c.Text(), format, args...)
+}
+
+// Wrap places an error found in the current line at it.
+func (c *LineCursor) Wrap(err error) error {
+	return AtLine(err, c.Line(), c.Text())
+}
+
+// SkipSpaces moves past any white space.
+func (c *LineCursor) SkipSpaces() {
+	rest := c.Rest()
+	c.col += len(rest) - len(strings.TrimLeftFunc(rest, unicode.IsSpace))
+}
+
+// Expect moves past the literal, which must come next.
+func (c *LineCursor) Expect(literal string) error {
+	if !strings.HasPrefix(c.Rest(), literal) {
+		return c.Errorf("expected %q", literal)
+	}
+
+	c.col += len(literal)
+
+	return nil
+}
+
+// ExpectEnd checks that nothing but white space is left of the line.
+func (c *LineCursor) ExpectEnd() error {
+	c.SkipSpaces()
+
+	if c.Rest() != "" {
+		return c.Errorf("unexpected %q", c.Rest())
+	}
+
+	return nil
+}
+
+// take moves past the longest prefix of the rest of the line whose runes
+// satisfy the function, and returns it.
+func (c *LineCursor) take(fn func(i int, r rune) bool) string {
+	rest := c.Rest()
+	end := len(rest)
+
+	for i, r := range rest {
+		if !fn(i, r) {
+			end = i
+			break
+		}
+	}
+
+	c.col += end
+
+	return rest[:end]
+}
+
+// Int moves past an integer, with an optional sign, and returns it.
+func (c *LineCursor) Int() (int, error) {
+	start := c.col
+	text := c.take(func(i int, r rune) bool {
+		return unicode.IsDigit(r) || (i == 0 && (r == '-' || r == '+'))
+	})
+
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		c.col = start
+		return 0, c.Errorf("expected a number")
+	}
+
+	return value, nil
+}
+
+// Word moves past a run of letters, digits and underscores, and returns it.
+func (c *LineCursor) Word() (string, error) {
+	word := c.take(func(i int, r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+	})
+
+	if word == "" {
+		return "", c.Errorf("expected a word")
+	}
+
+	return word, nil
+}
+
+// Until moves up to the separator, or to the end of the line when it isn't
+// found, and returns the text before it.
+func (c *LineCursor) Until(separator string) string {
+	rest := c.Rest()
+
+	if i := strings.Index(rest, separator); i >= 0 {
+		c.col += i
+		return rest[:i]
+	}
+
+	c.col += len(rest)
+
+	return rest
+}
+
+// TakeRest moves to the end of the line and returns what was left of it.
+func (c *LineCursor) TakeRest() string {
+	rest := c.Rest()
+	c.col += len(rest)
+
+	return rest
+}
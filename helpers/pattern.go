@@ -0,0 +1,378 @@
+package helpers
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// LIST_SEPARATOR separates the items of list fields in a pattern.
+const LIST_SEPARATOR = ","
+
+// patternPlaceholder matches a field in a pattern, such as {name} or
+// {rate:int}.
+var patternPlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(?::([^{}]+))?\}`)
+
+// patternTypes are the types a field can have, with the expression matching
+// it and how it's described in errors.
+var patternTypes = map[string]struct {
+	expression  string
+	description string
+}{
+	"string":   {`(.+?)`, "a value"},
+	"int":      {`([-+]?\d+)`, "a number"},
+	"[]string": {`(.*?)`, "a list"},
+	"[]int":    {`(.*?)`, "a list of numbers"},
+}
+
+// patternPiece is a literal or a field of a pattern.
+type patternPiece struct {
+	text        string
+	expression  string
+	description string
+	field       *patternField
+}
+
+// patternField is a field of a pattern and its type.
+type patternField struct {
+	name  string
+	kind  string
+	group int
+}
+
+// Pattern is a line format such as "move {count:int} from {from:int} to
+// {to:int}", made of literal text and fields with a name and an optional type:
+// string (the default), int, []string or []int. List items are separated by
+// commas, and a space in the pattern matches any run of spaces or tabs.
+type Pattern struct {
+	source   string
+	pieces   []patternPiece
+	fields   []*patternField
+	full     *regexp.Regexp
+	prefixes []*regexp.Regexp
+	sources  []string
+}
+
+var patternCache = struct {
+	sync.Mutex
+	patterns map[string]*Pattern
+}{patterns: make(map[string]*Pattern)}
+
+// CompilePattern returns the compiled pattern. Patterns are compiled once and
+// cached, so they can be used inside loops.
+func CompilePattern(pattern string) (*Pattern, error) {
+	patternCache.Lock()
+	defer patternCache.Unlock()
+
+	if p, ok := patternCache.patterns[pattern]; ok {
+		return p, nil
+	}
+
+	p, err := compilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patternCache.patterns[pattern] = p
+
+	return p, nil
+}
+
+// MustCompilePattern is like CompilePattern but panics if the pattern is
+// invalid.
+func MustCompilePattern(pattern string) *Pattern {
+	p, err := CompilePattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// literalExpression returns the expression matching literal text.
+func literalExpression(text string) string {
+	words := strings.Split(text, " ")
+
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+
+	return strings.Join(words, `[ \t]+`)
+}
+
+// compilePattern compiles a pattern into a regular expression, and into one
+// for every prefix of its pieces to tell how far a line matches.
+func compilePattern(pattern string) (*Pattern, error) {
+	p := &Pattern{source: pattern}
+	names := make(map[string]bool)
+	last := 0
+
+	for _, loc := range patternPlaceholder.FindAllStringSubmatchIndex(pattern, -1) {
+		if loc[0] > last {
+			text := pattern[last:loc[0]]
+			p.pieces = append(p.pieces, patternPiece{text, literalExpression(text), fmt.Sprintf("%q", text), nil})
+		}
+
+		name := pattern[loc[2]:loc[3]]
+		kind := "string"
+		if loc[4] >= 0 {
+			kind = strings.TrimSpace(pattern[loc[4]:loc[5]])
+		}
+
+		fieldType, ok := patternTypes[kind]
+		if !ok {
+			return nil, fmt.Errorf("pattern %q: field %s has unknown type %s", pattern, name, kind)
+		}
+
+		if names[name] {
+			return nil, fmt.Errorf("pattern %q: field %s is given more than once", pattern, name)
+		}
+		names[name] = true
+
+		field := &patternField{name, kind, len(p.fields) + 1}
+		p.fields = append(p.fields, field)
+		p.pieces = append(p.pieces, patternPiece{"", fieldType.expression, fmt.Sprintf("%s for %s", fieldType.description, name), field})
+
+		last = loc[1]
+	}
+
+	if last < len(pattern) {
+		text := pattern[last:]
+		p.pieces = append(p.pieces, patternPiece{text, literalExpression(text), fmt.Sprintf("%q", text), nil})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for _, piece := range p.pieces {
+		sb.WriteString(piece.expression)
+
+		// a prefix ending with a lazy field would match it empty, so it's
+		// matched greedily instead
+		prefix := sb.String()
+		p.sources = append(p.sources, prefix)
+		if piece.field != nil && strings.HasSuffix(prefix, "?)") {
+			prefix = strings.TrimSuffix(prefix, "?)") + ")"
+		}
+		p.prefixes = append(p.prefixes, regexp.MustCompile(prefix))
+	}
+
+	sb.WriteString(`[ \t]*$`)
+	p.full = regexp.MustCompile(sb.String())
+
+	return p, nil
+}
+
+// String returns the source of the pattern.
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// mismatch returns an error at the first piece of the pattern the line
+// doesn't match.
+func (p *Pattern) mismatch(line string) *ParseError {
+	col := 1
+
+	for i, prefix := range p.prefixes {
+		loc := prefix.FindStringIndex(line)
+		if loc == nil {
+			if start := p.literalStart(line, i); start > 0 {
+				col = start
+			}
+
+			return NewParseError(0, col, line, "expected %s", p.pieces[i].description)
+		}
+
+		col = loc[1] + 1
+	}
+
+	return NewParseError(0, col, line, "unexpected %q", line[col-1:])
+}
+
+// literalStart returns the column where the literal piece most likely starts
+// when it follows a field, which is where the longest start of the literal is
+// found after the field, or 0 when none of it is.
+func (p *Pattern) literalStart(line string, i int) int {
+	if i == 0 || p.pieces[i].field != nil || p.pieces[i-1].field == nil {
+		return 0
+	}
+
+	before := "^"
+	if i >= 2 {
+		before = p.sources[i-2]
+	}
+
+	literal := p.pieces[i].text
+	for k := len(literal); k > 0; k-- {
+		re, err := regexp.Compile(before + p.pieces[i-1].expression + "(" + literalExpression(literal[:k]) + ")")
+		if err != nil {
+			continue
+		}
+
+		if loc := re.FindStringSubmatchIndex(line); loc != nil {
+			return loc[2*(p.pieces[i-1].field.group+1)] + 1
+		}
+	}
+
+	return 0
+}
+
+// Match matches the line against the pattern and returns the text of each
+// field by name, and the column it starts at.
+func (p *Pattern) Match(line string) (map[string]Field, error) {
+	loc := p.full.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return nil, p.mismatch(line)
+	}
+
+	fields := make(map[string]Field, len(p.fields))
+
+	for _, field := range p.fields {
+		start, end := loc[2*field.group], loc[2*field.group+1]
+		fields[field.name] = Field{line[start:end], start + 1, line}
+	}
+
+	return fields, nil
+}
+
+// getStructField returns the field of the struct with a pattern tag of the
+// name, or else the one with the same name ignoring case.
+func getStructField(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if tag, ok := t.Field(i).Tag.Lookup("pattern"); ok && tag == name {
+			return v.Field(i), true
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if strings.EqualFold(t.Field(i).Name, name) {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// Unmarshal matches the line against the pattern and stores its fields in the
+// struct pointed to by v, whose fields are found by their pattern tag or by
+// their name ignoring case. String fields can also be stored as a Field, and
+// lists of strings as a []Field, to keep the columns they were found at.
+// Errors in the line are parse errors at the column where the line went wrong.
+func (p *Pattern) Unmarshal(line string, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("pattern %q: expected a pointer to a struct, got %T", p.source, v)
+	}
+	target = target.Elem()
+
+	fields, err := p.Match(line)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range p.fields {
+		value, ok := getStructField(target, field.name)
+		if !ok || !value.CanSet() {
+			return fmt.Errorf("pattern %q: %s has no settable field %s", p.source, target.Type(), field.name)
+		}
+
+		if err := setPatternField(value, field.kind, fields[field.name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getListItems splits a list field into its items, at their columns in the
+// line. An empty list has no items, but an empty item in a list is kept.
+func getListItems(field Field) []Field {
+	items := SplitFields(field.Text, LIST_SEPARATOR)
+
+	for i := range items {
+		items[i].Col += field.Col - 1
+		items[i].line = field.line
+	}
+
+	return items
+}
+
+// setPatternField converts the text of a field to its type and stores it.
+func setPatternField(value reflect.Value, kind string, field Field) error {
+	switch {
+	case kind == "string" && value.Kind() == reflect.String:
+		value.SetString(field.Text)
+	case kind == "string" && value.Type() == reflect.TypeOf(Field{}):
+		value.Set(reflect.ValueOf(field))
+	case kind == "int" && value.Kind() == reflect.Int:
+		n, err := field.Int()
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(n))
+	case kind == "[]string" && value.Type() == reflect.TypeOf([]string{}):
+		items := []string{}
+		for _, item := range getListItems(field) {
+			if item.Text == "" {
+				return item.Errorf("missing item")
+			}
+			items = append(items, item.Text)
+		}
+		value.Set(reflect.ValueOf(items))
+	case kind == "[]string" && value.Type() == reflect.TypeOf([]Field{}):
+		items := []Field{}
+		for _, item := range getListItems(field) {
+			if item.Text == "" {
+				return item.Errorf("missing item")
+			}
+			items = append(items, item)
+		}
+		value.Set(reflect.ValueOf(items))
+	case kind == "[]int" && value.Type() == reflect.TypeOf([]int{}):
+		items := []int{}
+		for _, item := range getListItems(field) {
+			n, err := item.Int()
+			if err != nil {
+				return err
+			}
+			items = append(items, n)
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("cannot store a field of type %s in a %s", kind, value.Type())
+	}
+
+	return nil
+}
+
+// UnmarshalLine stores the fields of the line in the struct pointed to by v,
+// using the first of the patterns the line matches. When it matches none,
+// the error is the one of the pattern it matches the furthest.
+func UnmarshalLine(line string, v interface{}, patterns ...string) error {
+	var best *ParseError
+
+	for _, pattern := range patterns {
+		p, err := CompilePattern(pattern)
+		if err != nil {
+			return err
+		}
+
+		if p.full.MatchString(line) {
+			return p.Unmarshal(line, v)
+		}
+
+		if mismatch := p.mismatch(line); best == nil || mismatch.Col > best.Col {
+			best = mismatch
+		}
+	}
+
+	if best == nil {
+		return fmt.Errorf("no patterns to match %q against", line)
+	}
+
+	return best
+}
@@ -0,0 +1,169 @@
+package helpers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPatternUnmarshalInts(t *testing.T) {
+	var point struct{ X, Y int }
+
+	if err := MustCompilePattern("{x:int},{y:int}").Unmarshal("-498,+4", &point); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if point.X != -498 || point.Y != 4 {
+		t.Errorf("got %+v, want {X:-498 Y:4}", point)
+	}
+}
+
+func TestPatternUnmarshalLists(t *testing.T) {
+	var valve struct {
+		Label string `pattern:"name"`
+		Rate  int
+		Next  []string
+	}
+
+	line := "Valve AA has flow rate=13; tunnels lead to valves DD, II,BB"
+	pattern := "Valve {name} has flow rate={rate:int}; tunnels lead to valves {next:[]string}"
+
+	if err := MustCompilePattern(pattern).Unmarshal(line, &valve); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if valve.Label != "AA" || valve.Rate != 13 || !reflect.DeepEqual(valve.Next, []string{"DD", "II", "BB"}) {
+		t.Errorf("got %+v", valve)
+	}
+
+	var items struct{ Items []int }
+	p := MustCompilePattern("Starting items:{items:[]int}")
+
+	tests := []struct {
+		line string
+		want []int
+	}{
+		{"Starting items: 79, 98", []int{79, 98}},
+		{"Starting items: 54", []int{54}},
+		{"Starting items:", []int{}},
+	}
+
+	for _, test := range tests {
+		if err := p.Unmarshal(test.line, &items); err != nil {
+			t.Errorf("%q: unexpected error: %s", test.line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(items.Items, test.want) {
+			t.Errorf("%q: got %v, want %v", test.line, items.Items, test.want)
+		}
+	}
+}
+
+func TestPatternErrorColumns(t *testing.T) {
+	type point struct{ X, Y int }
+	type monkey struct {
+		Name  string
+		Items []int
+	}
+
+	tests := []struct {
+		pattern string
+		line    string
+		target  interface{}
+		col     int
+		msg     string
+	}{
+		{"{x:int},{y:int}", "4,x", &point{}, 3, "expected a number for y"},
+		{"{x:int},{y:int}", "4;5", &point{}, 2, `expected ","`},
+		{"{x:int},{y:int}", "4,5 6", &point{}, 4, `unexpected " 6"`},
+		{"{x:int},{y:int}", "4,99999999999999999999", &point{}, 3, `invalid number "99999999999999999999"`},
+		{"{name} has {items:[]int}", "Bob had 1, 2", &monkey{}, 4, `expected " has "`},
+		{"{name} has {items:[]int}", "Bob has 1, x2", &monkey{}, 12, `invalid number "x2"`},
+		{"{name} has {items:[]int}", "Bob has 79, , 98", &monkey{}, 13, "missing number"},
+		{"Valve {name} leads to {next:[]string}", "Valve AA leads to DD,, II", &struct {
+			Name string
+			Next []string
+		}{}, 22, "missing item"},
+	}
+
+	for _, test := range tests {
+		err := MustCompilePattern(test.pattern).Unmarshal(test.line, test.target)
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: got %v, want a parse error", test.line, err)
+			continue
+		}
+
+		if parseErr.Col != test.col || parseErr.Msg != test.msg {
+			t.Errorf("%q: got column %d %q, want column %d %q", test.line, parseErr.Col, parseErr.Msg, test.col, test.msg)
+		}
+	}
+}
+
+func TestUnmarshalLineAlternatives(t *testing.T) {
+	var valve struct {
+		Name string
+		Next []string
+	}
+
+	patterns := []string{
+		"Valve {name} leads to valves {next:[]string}",
+		"Valve {name} leads to valve {next:[]string}",
+	}
+
+	if err := UnmarshalLine("Valve HH leads to valve GG", &valve, patterns...); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if valve.Name != "HH" || !reflect.DeepEqual(valve.Next, []string{"GG"}) {
+		t.Errorf("got %+v", valve)
+	}
+
+	err := UnmarshalLine("Valve HH leads to tunnel GG", &valve, patterns...)
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Col != 9 {
+		t.Errorf("got %v, want a parse error at column 9", err)
+	}
+}
+
+func TestCompilePatternCache(t *testing.T) {
+	a, err := CompilePattern("move {move:int} from {from:int} to {to:int}")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b := MustCompilePattern("move {move:int} from {from:int} to {to:int}")
+	if a != b {
+		t.Error("the pattern was compiled again instead of reused")
+	}
+
+	if _, err := CompilePattern("{x:float}"); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+
+	if _, err := CompilePattern("{x} and {x}"); err == nil {
+		t.Error("expected an error for a repeated field")
+	}
+}
+
+func TestPatternUnmarshalTargets(t *testing.T) {
+	p := MustCompilePattern("{x:int}")
+
+	var x int
+	if err := p.Unmarshal("1", &x); err == nil {
+		t.Error("expected an error for a target that isn't a struct")
+	}
+
+	var wrongType struct{ X string }
+	if err := p.Unmarshal("1", &wrongType); err == nil {
+		t.Error("expected an error for a field of the wrong type")
+	}
+
+	var unexported struct{ x int }
+	if err := p.Unmarshal("1", &unexported); err == nil {
+		t.Error("expected an error for an unexported field")
+	}
+}
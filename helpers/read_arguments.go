@@ -1,13 +1,19 @@
 package helpers
 
 import (
+	"flag"
 	"os"
 )
 
-// ReadArguments reads the arguments from the command line.
+// ReadArguments reads the arguments from the command line. Any flags declared
+// with the flag package are parsed first, so only positional arguments remain.
 func ReadArguments() []string {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
 	// get the filename from the command line
-	args := os.Args[1:]
+	args := flag.Args()
 
 	if len(args) < 1 {
 		os.Stderr.WriteString("you must supply a filename\n")
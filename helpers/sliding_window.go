@@ -0,0 +1,144 @@
+package helpers
+
+// Number is any integer or floating point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// windowEntry is a value in the window with the position it was pushed at.
+type windowEntry[T Number] struct {
+	position int
+	value    T
+}
+
+// SlidingWindow holds the last values pushed to it, up to its size, and keeps
+// their sum, minimum and maximum up to date in O(1) amortized time per push.
+type SlidingWindow[T Number] struct {
+	size   int
+	values []T
+	pushed int
+	sum    T
+	mins   []windowEntry[T]
+	maxs   []windowEntry[T]
+}
+
+// NewSlidingWindow returns an empty window of the given size, which must be at
+// least 1.
+func NewSlidingWindow[T Number](size int) *SlidingWindow[T] {
+	if size < 1 {
+		panic("the size of a sliding window must be at least 1")
+	}
+
+	return &SlidingWindow[T]{
+		size:   size,
+		values: make([]T, size),
+	}
+}
+
+// Push adds a value to the window, dropping the oldest one when it's full.
+func (w *SlidingWindow[T]) Push(value T) {
+	slot := w.pushed % w.size
+
+	if w.IsFull() {
+		w.sum -= w.values[slot]
+	}
+
+	w.values[slot] = value
+	w.sum += value
+	entry := windowEntry[T]{w.pushed, value}
+	w.pushed++
+
+	// the minimums and maximums are kept as monotonic queues, so the front of
+	// each one is the current minimum or maximum
+	oldest := w.pushed - w.size
+
+	for len(w.mins) > 0 && w.mins[len(w.mins)-1].value >= value {
+		w.mins = w.mins[:len(w.mins)-1]
+	}
+	w.mins = append(w.mins, entry)
+	if w.mins[0].position < oldest {
+		w.mins = w.mins[1:]
+	}
+
+	for len(w.maxs) > 0 && w.maxs[len(w.maxs)-1].value <= value {
+		w.maxs = w.maxs[:len(w.maxs)-1]
+	}
+	w.maxs = append(w.maxs, entry)
+	if w.maxs[0].position < oldest {
+		w.maxs = w.maxs[1:]
+	}
+}
+
+// Size returns the most values the window can hold.
+func (w *SlidingWindow[T]) Size() int {
+	return w.size
+}
+
+// Len returns the number of values in the window.
+func (w *SlidingWindow[T]) Len() int {
+	return MinOf(w.pushed, w.size)
+}
+
+// IsFull checks if the window holds as many values as its size.
+func (w *SlidingWindow[T]) IsFull() bool {
+	return w.pushed >= w.size
+}
+
+// Sum returns the sum of the values in the window.
+func (w *SlidingWindow[T]) Sum() T {
+	return w.sum
+}
+
+// Mean returns the mean of the values in the window, or 0 if it's empty.
+func (w *SlidingWindow[T]) Mean() float64 {
+	if w.pushed == 0 {
+		return 0
+	}
+
+	return float64(w.sum) / float64(w.Len())
+}
+
+// Min returns the smallest value in the window, or 0 if it's empty.
+func (w *SlidingWindow[T]) Min() T {
+	if len(w.mins) == 0 {
+		return 0
+	}
+
+	return w.mins[0].value
+}
+
+// Max returns the largest value in the window, or 0 if it's empty.
+func (w *SlidingWindow[T]) Max() T {
+	if len(w.maxs) == 0 {
+		return 0
+	}
+
+	return w.maxs[0].value
+}
+
+// Values returns the values in the window, from the oldest to the newest.
+func (w *SlidingWindow[T]) Values() []T {
+	values := make([]T, 0, w.Len())
+
+	for i := w.pushed - w.Len(); i < w.pushed; i++ {
+		values = append(values, w.values[i%w.size])
+	}
+
+	return values
+}
+
+// SlideOver pushes the values one at a time to a window of the given size,
+// calling the function every time the window is full.
+func SlideOver[T Number](values []T, size int, fn func(w *SlidingWindow[T])) {
+	w := NewSlidingWindow[T](size)
+
+	for _, value := range values {
+		w.Push(value)
+
+		if w.IsFull() {
+			fn(w)
+		}
+	}
+}
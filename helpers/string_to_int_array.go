@@ -1,22 +1,17 @@
 package helpers
 
-import (
-	"strconv"
-	"strings"
-)
-
 // StringToIntArray converts a string to an array of ints.
-func StringToIntArray(str string, separator string) []int {
+func StringToIntArray(str string, separator string) ([]int, error) {
 	var result []int
 
-	for _, numStr := range strings.Split(str, separator) {
-		cleanNumStr := strings.TrimSpace(numStr)
-
-		if cleanNumStr != "" {
-			num, _ := strconv.Atoi(cleanNumStr)
-			result = append(result, num)
+	for _, field := range SplitFields(str, separator) {
+		num, err := field.Int()
+		if err != nil {
+			return nil, err
 		}
+
+		result = append(result, num)
 	}
 
-	return result
+	return result, nil
 }